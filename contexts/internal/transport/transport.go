@@ -0,0 +1,152 @@
+// Package transport contains the HTTP and gRPC plumbing shared by the
+// context propagation packages.
+package transport
+
+import (
+	"context"
+	"net/http"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Injector adds the values stored in a context to outgoing request headers
+// or metadata. It must not add anything if there is nothing to propagate.
+type Injector interface {
+	InjectHTTP(ctx context.Context, h http.Header)
+	InjectGRPC(ctx context.Context, md metadata.MD)
+}
+
+// Extractor returns a copy of a context with the values found in incoming
+// request headers or metadata.
+type Extractor interface {
+	ExtractHTTP(ctx context.Context, h http.Header) context.Context
+	ExtractGRPC(ctx context.Context, md metadata.MD) context.Context
+}
+
+// Attach returns a go-kit gRPC client option which injects into the request
+// metadata.
+func Attach(in Injector) grpctransport.ClientOption {
+	return grpctransport.ClientBefore(
+		func(ctx context.Context, md *metadata.MD) context.Context {
+			in.InjectGRPC(ctx, *md)
+			return ctx
+		},
+	)
+}
+
+// Extract returns a go-kit gRPC server option which extracts from the request
+// metadata.
+func Extract(ex Extractor) grpctransport.ServerOption {
+	return grpctransport.ServerBefore(ex.ExtractGRPC)
+}
+
+// UnaryClientInterceptor is the native gRPC equivalent of Attach for unary
+// RPCs.
+func UnaryClientInterceptor(in Injector) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx, in), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the native gRPC equivalent of Attach for
+// streaming RPCs.
+func StreamClientInterceptor(in Injector) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx, in), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor is the native gRPC equivalent of Extract for unary
+// RPCs.
+func UnaryServerInterceptor(ex Extractor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return handler(ex.ExtractGRPC(ctx, md), req)
+	}
+}
+
+// StreamServerInterceptor is the native gRPC equivalent of Extract for
+// streaming RPCs.
+func StreamServerInterceptor(ex Extractor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		return handler(srv, ServerStream(ss, ex.ExtractGRPC(ss.Context(), md)))
+	}
+}
+
+// outgoing adds the values injected from ctx to its outgoing gRPC metadata.
+func outgoing(ctx context.Context, in Injector) context.Context {
+	injected := metadata.MD{}
+	in.InjectGRPC(ctx, injected)
+	if len(injected) == 0 {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	for k, v := range injected {
+		md[k] = v
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// Middleware returns HTTP middleware which extracts from the request headers
+// into the request context.
+func Middleware(ex Extractor, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ex.ExtractHTTP(r.Context(), r.Header)))
+	})
+}
+
+// NewTransport returns an http.RoundTripper which injects into the request
+// headers before passing the request to next. If next is nil,
+// http.DefaultTransport is used.
+func NewTransport(in Injector, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		injected := http.Header{}
+		in.InjectHTTP(r.Context(), injected)
+		return next.RoundTrip(WithHeaders(r, injected))
+	})
+}
+
+// WithHeaders returns r if h is empty, and otherwise a copy of r with the
+// headers in h set. A RoundTripper must not modify the request it is given,
+// so r itself is never changed.
+func WithHeaders(r *http.Request, h http.Header) *http.Request {
+	if len(h) == 0 {
+		return r
+	}
+	r = r.Clone(r.Context())
+	for k, v := range h {
+		r.Header[k] = v
+	}
+	return r
+}
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as
+// an http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls fn(r).
+func (fn RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
+
+// ServerStream returns ss with its context replaced by ctx.
+func ServerStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
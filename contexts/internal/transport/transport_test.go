@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+type valueKey struct{}
+
+// testPropagator propagates a single value stored in the context.
+type testPropagator struct{}
+
+func (testPropagator) InjectHTTP(ctx context.Context, h http.Header) {
+	if v, ok := ctx.Value(valueKey{}).(string); ok {
+		h.Set("X-Value", v)
+	}
+}
+
+func (testPropagator) InjectGRPC(ctx context.Context, md metadata.MD) {
+	if v, ok := ctx.Value(valueKey{}).(string); ok {
+		md.Set("x-value", v)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	rt := NewTransport(testPropagator{}, RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		got = append(got, r.Header.Get("X-Value"))
+		return httptest.NewRecorder().Result(), nil
+	}))
+
+	for _, ctx := range []context.Context{
+		context.Background(),
+		context.WithValue(context.Background(), valueKey{}, "abc"),
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		_, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		require.Empty(t, req.Header.Get("X-Value"), "the request given to RoundTrip must not be modified")
+	}
+	require.Equal(t, []string{"", "abc"}, got)
+}
+
+func TestOutgoing(t *testing.T) {
+	t.Parallel()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "other", "kept")
+	require.Equal(t, ctx, outgoing(ctx, testPropagator{}))
+
+	ctx = outgoing(context.WithValue(ctx, valueKey{}, "abc"), testPropagator{})
+	md, _ := metadata.FromOutgoingContext(ctx)
+	require.Equal(t, []string{"abc"}, md.Get("x-value"))
+	require.Equal(t, []string{"kept"}, md.Get("other"))
+}
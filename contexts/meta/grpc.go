@@ -0,0 +1,76 @@
+package meta
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kolide/kit/contexts/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// InjectGRPC adds a metadata entry to md for every propagated value stored
+// in ctx. Keys are lower case and values are URL encoded.
+func (p *Propagator) InjectGRPC(ctx context.Context, md metadata.MD) {
+	values, _ := ctx.Value(metaKey).(map[Key]string)
+	prefix := strings.ToLower(p.prefix)
+	for _, k := range p.propagated(ctx) {
+		md.Set(prefix+string(k), url.QueryEscape(values[k]))
+	}
+}
+
+// ExtractGRPC returns a copy of ctx with the propagated values found in md.
+// Entries for keys which are not propagated are ignored.
+func (p *Propagator) ExtractGRPC(ctx context.Context, md metadata.MD) context.Context {
+	found := make(map[Key]string)
+	prefix := strings.ToLower(p.prefix)
+	for name, vals := range md {
+		if !strings.HasPrefix(name, prefix) || len(vals) == 0 {
+			continue
+		}
+		v, err := url.QueryUnescape(vals[0])
+		if err != nil {
+			continue
+		}
+		found[Key(strings.TrimPrefix(name, prefix))] = v
+	}
+	return p.extract(ctx, found)
+}
+
+// Attach adds the propagated values stored in context to the gRPC request
+// metadata.
+func (p *Propagator) Attach() grpctransport.ClientOption {
+	return transport.Attach(p)
+}
+
+// Extract stores the propagated values found in the gRPC request metadata in
+// the request context.
+func (p *Propagator) Extract() grpctransport.ServerOption {
+	return transport.Extract(p)
+}
+
+// UnaryClientInterceptor is the native gRPC equivalent of Attach for unary
+// RPCs.
+func (p *Propagator) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return transport.UnaryClientInterceptor(p)
+}
+
+// StreamClientInterceptor is the native gRPC equivalent of Attach for
+// streaming RPCs.
+func (p *Propagator) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return transport.StreamClientInterceptor(p)
+}
+
+// UnaryServerInterceptor is the native gRPC equivalent of Extract for unary
+// RPCs.
+func (p *Propagator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return transport.UnaryServerInterceptor(p)
+}
+
+// StreamServerInterceptor is the native gRPC equivalent of Extract for
+// streaming RPCs.
+func (p *Propagator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return transport.StreamServerInterceptor(p)
+}
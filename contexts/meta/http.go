@@ -0,0 +1,52 @@
+package meta
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kolide/kit/contexts/internal/transport"
+)
+
+// InjectHTTP sets a header for every propagated value stored in ctx.
+// Values are URL encoded.
+func (p *Propagator) InjectHTTP(ctx context.Context, h http.Header) {
+	values, _ := ctx.Value(metaKey).(map[Key]string)
+	for _, k := range p.propagated(ctx) {
+		h.Set(p.prefix+string(k), url.QueryEscape(values[k]))
+	}
+}
+
+// ExtractHTTP returns a copy of ctx with the propagated values found in h.
+// Headers for keys which are not propagated are ignored.
+func (p *Propagator) ExtractHTTP(ctx context.Context, h http.Header) context.Context {
+	found := make(map[Key]string)
+	prefix := strings.ToLower(p.prefix)
+	for name, vals := range h {
+		name = strings.ToLower(name)
+		if !strings.HasPrefix(name, prefix) || len(vals) == 0 {
+			continue
+		}
+		v, err := url.QueryUnescape(vals[0])
+		if err != nil {
+			continue
+		}
+		found[Key(strings.TrimPrefix(name, prefix))] = v
+	}
+	return p.extract(ctx, found)
+}
+
+// Middleware returns HTTP middleware which stores the propagated values found
+// in the request headers in the request context. It can be used with
+// httputil.Chain.
+func (p *Propagator) Middleware(next http.Handler) http.Handler {
+	return transport.Middleware(p, next)
+}
+
+// NewTransport returns an http.RoundTripper which sets headers for the
+// propagated values in the request context before passing the request to
+// next. If next is nil, http.DefaultTransport is used.
+func (p *Propagator) NewTransport(next http.RoundTripper) http.RoundTripper {
+	return transport.NewTransport(p, next)
+}
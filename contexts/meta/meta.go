@@ -0,0 +1,211 @@
+// Package meta provides an allow-listed bag of request-scoped metadata, like
+// tenant and actor IDs or feature flags, which follows a request across
+// services through HTTP headers and gRPC metadata.
+//
+// Only keys registered with a Propagator can be set, and only keys registered
+// with WithPropagatedKey cross service boundaries.
+package meta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Key identifies a metadata value. Keys are lower case, and must be valid
+// HTTP header field names.
+type Key string
+
+// Well known keys.
+const (
+	TenantID     Key = "tenant-id"
+	ActorID      Key = "actor-id"
+	FeatureFlags Key = "feature-flags"
+)
+
+// Use a private type to prevent name collisions with other packages.
+type key string
+
+const metaKey key = "meta"
+
+// Get returns the value of k stored in ctx, if any.
+func Get(ctx context.Context, k Key) (string, bool) {
+	values, _ := ctx.Value(metaKey).(map[Key]string)
+	v, ok := values[k]
+	return v, ok
+}
+
+// All returns a copy of all the metadata stored in ctx.
+func All(ctx context.Context) map[Key]string {
+	values, _ := ctx.Value(metaKey).(map[Key]string)
+	out := make(map[Key]string, len(values))
+	for k, v := range values {
+		out[k] = v
+	}
+	return out
+}
+
+// TenantIDFromContext returns the tenant ID stored in ctx, if any.
+func TenantIDFromContext(ctx context.Context) (string, bool) {
+	return Get(ctx, TenantID)
+}
+
+// ActorIDFromContext returns the actor ID stored in ctx, if any.
+func ActorIDFromContext(ctx context.Context) (string, bool) {
+	return Get(ctx, ActorID)
+}
+
+// FeatureFlagsFromContext returns the feature flags stored in ctx. Flags are
+// stored as a comma separated list, see Propagator.SetFeatureFlags.
+func FeatureFlagsFromContext(ctx context.Context) []string {
+	v, ok := Get(ctx, FeatureFlags)
+	if !ok || v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// Option configures a Propagator.
+type Option func(*Propagator)
+
+// WithKey allows k to be stored in a context. The value is not propagated
+// to other services.
+func WithKey(k Key) Option {
+	return func(p *Propagator) {
+		p.keys[Key(strings.ToLower(string(k)))] = false
+	}
+}
+
+// WithPropagatedKey allows k to be stored in a context and propagated to
+// other services.
+func WithPropagatedKey(k Key) Option {
+	return func(p *Propagator) {
+		p.keys[Key(strings.ToLower(string(k)))] = true
+	}
+}
+
+// WithPrefix sets the prefix of the HTTP headers and gRPC metadata keys used
+// to propagate values. The default is "Kolide-Meta-".
+func WithPrefix(prefix string) Option {
+	return func(p *Propagator) {
+		p.prefix = prefix
+	}
+}
+
+// WithMaxValueSize limits the size in bytes of a single value.
+// The default is 256 bytes.
+func WithMaxValueSize(n int) Option {
+	return func(p *Propagator) {
+		p.maxValueSize = n
+	}
+}
+
+// WithMaxTotalSize limits the combined size in bytes of all the keys and
+// values stored in a context. The default is 4096 bytes.
+func WithMaxTotalSize(n int) Option {
+	return func(p *Propagator) {
+		p.maxTotalSize = n
+	}
+}
+
+// Propagator stores allow-listed metadata in contexts and moves it across
+// service boundaries.
+type Propagator struct {
+	prefix       string
+	keys         map[Key]bool
+	maxValueSize int
+	maxTotalSize int
+}
+
+// NewPropagator creates a Propagator. Keys must be allowed with WithKey or
+// WithPropagatedKey before they can be used.
+func NewPropagator(opts ...Option) *Propagator {
+	p := &Propagator{
+		prefix:       "Kolide-Meta-",
+		keys:         make(map[Key]bool),
+		maxValueSize: 256,
+		maxTotalSize: 4096,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Set returns a copy of ctx with k set to value. It returns an error if k is
+// not allowed or the value exceeds the size limits.
+func (p *Propagator) Set(ctx context.Context, k Key, value string) (context.Context, error) {
+	k = Key(strings.ToLower(string(k)))
+	if _, ok := p.keys[k]; !ok {
+		return ctx, fmt.Errorf("metadata key %q is not allowed", k)
+	}
+	if len(value) > p.maxValueSize {
+		return ctx, fmt.Errorf("metadata value for %q is %d bytes, the limit is %d", k, len(value), p.maxValueSize)
+	}
+
+	values := All(ctx)
+	values[k] = value
+	if size := totalSize(values); size > p.maxTotalSize {
+		return ctx, fmt.Errorf("metadata is %d bytes, the limit is %d", size, p.maxTotalSize)
+	}
+	return context.WithValue(ctx, metaKey, values), nil
+}
+
+// SetFeatureFlags stores flags under the FeatureFlags key as a comma
+// separated list.
+func (p *Propagator) SetFeatureFlags(ctx context.Context, flags ...string) (context.Context, error) {
+	return p.Set(ctx, FeatureFlags, strings.Join(flags, ","))
+}
+
+// propagated returns the metadata in ctx which may cross service boundaries,
+// sorted by key.
+func (p *Propagator) propagated(ctx context.Context) []Key {
+	values, _ := ctx.Value(metaKey).(map[Key]string)
+	var keys []Key
+	for k := range values {
+		if p.keys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// extract stores the propagated values found by a transport in ctx, ignoring
+// keys which are not propagated and values beyond the size limits.
+func (p *Propagator) extract(ctx context.Context, found map[Key]string) context.Context {
+	keys := make([]Key, 0, len(found))
+	for k := range found {
+		keys = append(keys, k)
+	}
+	// sort the keys so the same values are kept when the limit is reached
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	values := All(ctx)
+	var changed bool
+	for _, k := range keys {
+		v := found[k]
+		if !p.keys[k] || len(v) > p.maxValueSize {
+			continue
+		}
+		values[k] = v
+		if totalSize(values) > p.maxTotalSize {
+			delete(values, k)
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return ctx
+	}
+	return context.WithValue(ctx, metaKey, values)
+}
+
+func totalSize(values map[Key]string) int {
+	var size int
+	for k, v := range values {
+		size += len(k) + len(v)
+	}
+	return size
+}
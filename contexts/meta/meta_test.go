@@ -0,0 +1,159 @@
+package meta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const localOnly Key = "debug-session"
+
+func newTestPropagator() *Propagator {
+	return NewPropagator(
+		WithPropagatedKey(TenantID),
+		WithPropagatedKey(ActorID),
+		WithPropagatedKey(FeatureFlags),
+		WithKey(localOnly),
+		WithMaxValueSize(32),
+		WithMaxTotalSize(64),
+	)
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPropagator()
+
+	var tests = []struct {
+		name      string
+		key       Key
+		value     string
+		expectErr bool
+	}{
+		{name: "propagated", key: TenantID, value: "acme"},
+		{name: "local", key: localOnly, value: "abc"},
+		{name: "case insensitive", key: "Tenant-ID", value: "acme"},
+		{name: "not allowed", key: "user-email", value: "a@example.com", expectErr: true},
+		{name: "value too large", key: TenantID, value: strings.Repeat("a", 33), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, err := p.Set(context.Background(), tt.key, tt.value)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			v, ok := Get(ctx, Key(strings.ToLower(string(tt.key))))
+			require.True(t, ok)
+			require.Equal(t, tt.value, v)
+		})
+	}
+
+	// the total size limit applies across keys
+	ctx, err := p.Set(context.Background(), TenantID, strings.Repeat("a", 30))
+	require.NoError(t, err)
+	_, err = p.Set(ctx, ActorID, strings.Repeat("b", 30))
+	require.Error(t, err)
+
+	ctx, err = p.SetFeatureFlags(context.Background(), "new-ui", "beta")
+	require.NoError(t, err)
+	require.Equal(t, []string{"new-ui", "beta"}, FeatureFlagsFromContext(ctx))
+}
+
+func testContext(t *testing.T, p *Propagator) context.Context {
+	ctx := context.Background()
+	ctx, err := p.Set(ctx, TenantID, "acme corp")
+	require.NoError(t, err)
+	ctx, err = p.Set(ctx, ActorID, "alice")
+	require.NoError(t, err)
+	ctx, err = p.Set(ctx, localOnly, "secret")
+	require.NoError(t, err)
+	return ctx
+}
+
+func requirePropagated(t *testing.T, ctx context.Context) {
+	tenant, ok := TenantIDFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "acme corp", tenant)
+	actor, ok := ActorIDFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "alice", actor)
+	_, ok = Get(ctx, localOnly)
+	require.False(t, ok, "local keys must not be propagated")
+}
+
+func TestHTTP(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPropagator()
+
+	var (
+		serverCtx    context.Context
+		tenantHeader string
+	)
+	srv := httptest.NewServer(p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantHeader = r.Header.Get("Kolide-Meta-Tenant-Id")
+		serverCtx = r.Context()
+	})))
+	defer srv.Close()
+
+	client := &http.Client{Transport: p.NewTransport(nil)}
+	req, err := http.NewRequestWithContext(testContext(t, p), "GET", srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, "acme+corp", tenantHeader)
+	requirePropagated(t, serverCtx)
+	require.Empty(t, req.Header, "the original request must not be modified")
+}
+
+func TestExtractHTTPIgnoresUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPropagator()
+	h := http.Header{}
+	h.Set("Kolide-Meta-Debug-Session", "abc")
+	h.Set("Kolide-Meta-User-Email", "a@example.com")
+	h.Set("Kolide-Meta-Tenant-Id", strings.Repeat("a", 40))
+
+	ctx := p.ExtractHTTP(context.Background(), h)
+	require.Empty(t, All(ctx))
+}
+
+func TestGRPC(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPropagator()
+
+	var outgoing metadata.MD
+	err := p.UnaryClientInterceptor()(testContext(t, p), "/test", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			outgoing, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []string{"acme+corp"}, outgoing.Get("kolide-meta-tenant-id"))
+
+	var serverCtx context.Context
+	ctx := metadata.NewIncomingContext(context.Background(), outgoing)
+	_, err = p.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			serverCtx = ctx
+			return nil, nil
+		})
+	require.NoError(t, err)
+	requirePropagated(t, serverCtx)
+}
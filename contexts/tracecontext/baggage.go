@@ -0,0 +1,107 @@
+package tracecontext
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Limits defined by the W3C Baggage specification.
+const (
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+// Baggage is the parsed form of the baggage header, a list of application
+// defined key/value pairs propagated with the trace.
+type Baggage []BaggageMember
+
+// BaggageMember is a single baggage entry. Properties are kept in their
+// encoded form, as they are opaque to this package.
+type BaggageMember struct {
+	Key        string
+	Value      string
+	Properties []string
+}
+
+// ParseBaggage parses a baggage header value. Values are percent decoded.
+func ParseBaggage(s string) (Baggage, error) {
+	if len(s) > maxBaggageBytes {
+		return nil, fmt.Errorf("baggage is %d bytes, the limit is %d", len(s), maxBaggageBytes)
+	}
+	var b Baggage
+	for _, member := range strings.Split(s, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		parts := strings.Split(member, ";")
+		k, v, ok := strings.Cut(parts[0], "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("baggage member %q is malformed", member)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("decoding baggage value for %q: %w", k, err)
+		}
+		m := BaggageMember{Key: k, Value: value}
+		for _, p := range parts[1:] {
+			if p = strings.TrimSpace(p); p != "" {
+				m.Properties = append(m.Properties, p)
+			}
+		}
+		b = append(b, m)
+	}
+	if len(b) > maxBaggageMembers {
+		return nil, fmt.Errorf("baggage has %d members, the limit is %d", len(b), maxBaggageMembers)
+	}
+	return b, nil
+}
+
+// Get returns the value for key, if any.
+func (b Baggage) Get(key string) (string, bool) {
+	for _, m := range b {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set returns a copy of b with key set to value, replacing any existing
+// member with the same key.
+func (b Baggage) Set(key, value string) Baggage {
+	out := make(Baggage, 0, len(b)+1)
+	for _, m := range b {
+		if m.Key != key {
+			out = append(out, m)
+		}
+	}
+	return append(out, BaggageMember{Key: key, Value: value})
+}
+
+// String formats the baggage header value. Values are percent encoded.
+func (b Baggage) String() string {
+	members := make([]string, len(b))
+	for i, m := range b {
+		s := m.Key + "=" + url.PathEscape(m.Value)
+		for _, p := range m.Properties {
+			s += ";" + p
+		}
+		members[i] = s
+	}
+	return strings.Join(members, ",")
+}
+
+// NewBaggageContext creates a new context with the baggage set to b.
+func NewBaggageContext(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageKey, b)
+}
+
+// BaggageFromContext returns the baggage stored in ctx, if any.
+func BaggageFromContext(ctx context.Context) (Baggage, bool) {
+	b, ok := ctx.Value(baggageKey).(Baggage)
+	return b, ok
+}
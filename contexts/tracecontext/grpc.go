@@ -0,0 +1,71 @@
+package tracecontext
+
+import (
+	"context"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kolide/kit/contexts/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// grpcHeader adapts metadata.MD to the header interface. gRPC metadata keys
+// are lower case, matching the W3C header names.
+type grpcHeader metadata.MD
+
+func (h grpcHeader) get(name string) string {
+	if vals := metadata.MD(h).Get(name); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+func (h grpcHeader) set(name, value string) { metadata.MD(h).Set(name, value) }
+
+// InjectGRPC adds the traceparent, tracestate and baggage entries to md from
+// the values stored in ctx.
+func (p *Propagator) InjectGRPC(ctx context.Context, md metadata.MD) {
+	p.inject(ctx, grpcHeader(md))
+}
+
+// ExtractGRPC returns a copy of ctx with the trace context and baggage found
+// in md.
+func (p *Propagator) ExtractGRPC(ctx context.Context, md metadata.MD) context.Context {
+	return p.extract(ctx, grpcHeader(md))
+}
+
+// Attach adds the trace context and baggage stored in context to the gRPC
+// request metadata.
+func (p *Propagator) Attach() grpctransport.ClientOption {
+	return transport.Attach(p)
+}
+
+// Extract stores the trace context and baggage found in the gRPC request
+// metadata in the request context.
+func (p *Propagator) Extract() grpctransport.ServerOption {
+	return transport.Extract(p)
+}
+
+// UnaryClientInterceptor is the native gRPC equivalent of Attach for unary
+// RPCs.
+func (p *Propagator) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return transport.UnaryClientInterceptor(p)
+}
+
+// StreamClientInterceptor is the native gRPC equivalent of Attach for
+// streaming RPCs.
+func (p *Propagator) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return transport.StreamClientInterceptor(p)
+}
+
+// UnaryServerInterceptor is the native gRPC equivalent of Extract for unary
+// RPCs.
+func (p *Propagator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return transport.UnaryServerInterceptor(p)
+}
+
+// StreamServerInterceptor is the native gRPC equivalent of Extract for
+// streaming RPCs.
+func (p *Propagator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return transport.StreamServerInterceptor(p)
+}
@@ -0,0 +1,44 @@
+package tracecontext
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kolide/kit/contexts/internal/transport"
+)
+
+// httpHeader adapts http.Header to the header interface.
+type httpHeader http.Header
+
+func (h httpHeader) get(name string) string { return http.Header(h).Get(name) }
+func (h httpHeader) set(name, value string) { http.Header(h).Set(name, value) }
+
+// InjectHTTP sets the traceparent, tracestate and baggage headers from the
+// values stored in ctx.
+func (p *Propagator) InjectHTTP(ctx context.Context, h http.Header) {
+	p.inject(ctx, httpHeader(h))
+}
+
+// ExtractHTTP returns a copy of ctx with the trace context and baggage found
+// in h.
+func (p *Propagator) ExtractHTTP(ctx context.Context, h http.Header) context.Context {
+	return p.extract(ctx, httpHeader(h))
+}
+
+// Middleware returns HTTP middleware which stores the trace context and
+// baggage found in the request headers in the request context. It can be used
+// with httputil.Chain.
+//
+// When used with WithDerivedUUID, place it before uuid.Middleware in the
+// chain, so the derived UUID is kept for requests without an X-Request-Id
+// header.
+func (p *Propagator) Middleware(next http.Handler) http.Handler {
+	return transport.Middleware(p, next)
+}
+
+// NewTransport returns an http.RoundTripper which sets the traceparent,
+// tracestate and baggage headers from the request context before passing the
+// request to next. If next is nil, http.DefaultTransport is used.
+func (p *Propagator) NewTransport(next http.RoundTripper) http.RoundTripper {
+	return transport.NewTransport(p, next)
+}
@@ -0,0 +1,93 @@
+package tracecontext
+
+import (
+	"context"
+
+	"github.com/kolide/kit/contexts/uuid"
+)
+
+// Option configures a Propagator.
+type Option func(*Propagator)
+
+// WithDerivedUUID sets the request UUID from the contexts/uuid package to the
+// trace ID, formatted as a UUID, when the context does not already carry one.
+// This lets log records and traces for a request be correlated by a single ID.
+func WithDerivedUUID() Option {
+	return func(p *Propagator) {
+		p.deriveUUID = true
+	}
+}
+
+// WithoutNewTraces disables starting a new trace when a request does not
+// carry a valid traceparent header. By default a new trace is started, so
+// every request has a trace context.
+func WithoutNewTraces() Option {
+	return func(p *Propagator) {
+		p.noNewTraces = true
+	}
+}
+
+// Propagator reads and writes the W3C traceparent, tracestate and baggage
+// headers of HTTP requests and gRPC metadata.
+type Propagator struct {
+	deriveUUID  bool
+	noNewTraces bool
+}
+
+// NewPropagator creates a Propagator.
+func NewPropagator(opts ...Option) *Propagator {
+	p := &Propagator{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// header is implemented by both http.Header and metadata.MD adapters.
+type header interface {
+	get(name string) string
+	set(name, value string)
+}
+
+// inject writes the trace context and baggage stored in ctx to h.
+func (p *Propagator) inject(ctx context.Context, h header) {
+	if tc, ok := FromContext(ctx); ok && tc.Parent.IsValid() {
+		h.set(TraceParentHeader, tc.Parent.String())
+		if len(tc.State) > 0 {
+			h.set(TraceStateHeader, tc.State.String())
+		}
+	}
+	if b, ok := BaggageFromContext(ctx); ok && len(b) > 0 {
+		h.set(BaggageHeader, b.String())
+	}
+}
+
+// extract returns a copy of ctx with the trace context and baggage found in
+// h. The stored traceparent identifies the span of the receiving service, so
+// it carries the caller's trace ID and a new parent ID. Invalid headers are
+// ignored, as the specification requires.
+func (p *Propagator) extract(ctx context.Context, h header) context.Context {
+	var tc TraceContext
+	parent, err := ParseTraceParent(h.get(TraceParentHeader))
+	if err == nil {
+		tc.Parent, err = parent.Child()
+		if state, serr := ParseTraceState(h.get(TraceStateHeader)); serr == nil {
+			tc.State = state
+		}
+	} else if !p.noNewTraces {
+		tc.Parent, err = New()
+	}
+	if err == nil {
+		ctx = NewContext(ctx, tc)
+		if p.deriveUUID {
+			if id, ok := uuid.FromContext(ctx); !ok || id == "" {
+				ctx = uuid.NewContext(ctx, tc.Parent.TraceID.UUID())
+			}
+		}
+	}
+
+	if b, err := ParseBaggage(h.get(BaggageHeader)); err == nil && len(b) > 0 {
+		ctx = NewBaggageContext(ctx, b)
+	}
+	return ctx
+}
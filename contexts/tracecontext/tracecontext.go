@@ -0,0 +1,259 @@
+// Package tracecontext implements the W3C Trace Context and Baggage headers,
+// so request IDs interoperate with proxies and services in other languages.
+//
+// See https://www.w3.org/TR/trace-context/ and https://www.w3.org/TR/baggage/.
+package tracecontext
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Header names defined by the W3C specifications.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+	BaggageHeader     = "baggage"
+)
+
+// FlagSampled is the sampled bit of the trace flags.
+const FlagSampled byte = 0x01
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// String returns the trace ID as 32 lower case hex characters.
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid reports whether the trace ID is not all zeros.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// UUID formats the trace ID as a UUID, so it can be used as the request UUID
+// from the contexts/uuid package.
+func (t TraceID) UUID() string {
+	return uuid.UUID(t).String()
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the span ID as 16 lower case hex characters.
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid reports whether the span ID is not all zeros.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// TraceParent is the parsed form of the traceparent header.
+type TraceParent struct {
+	Version  byte
+	TraceID  TraceID
+	ParentID SpanID
+	Flags    byte
+}
+
+// New generates a traceparent for a new, sampled trace.
+func New() (TraceParent, error) {
+	var tp TraceParent
+	if _, err := rand.Read(tp.TraceID[:]); err != nil {
+		return tp, fmt.Errorf("generating trace id: %w", err)
+	}
+	if _, err := rand.Read(tp.ParentID[:]); err != nil {
+		return tp, fmt.Errorf("generating parent id: %w", err)
+	}
+	tp.Flags = FlagSampled
+	return tp, nil
+}
+
+// Child returns a traceparent for a new span in the same trace, to be sent
+// on outgoing requests.
+func (tp TraceParent) Child() (TraceParent, error) {
+	child := tp
+	child.Version = 0
+	if _, err := rand.Read(child.ParentID[:]); err != nil {
+		return child, fmt.Errorf("generating parent id: %w", err)
+	}
+	return child, nil
+}
+
+// Sampled reports whether the sampled flag is set.
+func (tp TraceParent) Sampled() bool { return tp.Flags&FlagSampled != 0 }
+
+// IsValid reports whether both the trace and parent IDs are valid.
+func (tp TraceParent) IsValid() bool { return tp.TraceID.IsValid() && tp.ParentID.IsValid() }
+
+// String formats the traceparent header value.
+func (tp TraceParent) String() string {
+	return fmt.Sprintf("%02x-%s-%s-%02x", tp.Version, tp.TraceID, tp.ParentID, tp.Flags)
+}
+
+// ParseTraceParent parses a traceparent header value.
+// Values with a future version are accepted as long as they start with the
+// fields defined by version 00.
+func ParseTraceParent(s string) (TraceParent, error) {
+	var tp TraceParent
+	s = strings.TrimSpace(s)
+	if len(s) < 55 {
+		return tp, errors.New("traceparent is too short")
+	}
+	if s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return tp, errors.New("traceparent is malformed")
+	}
+
+	version, err := decodeHex(s[0:2], 1)
+	if err != nil {
+		return tp, fmt.Errorf("parsing traceparent version: %w", err)
+	}
+	tp.Version = version[0]
+	if tp.Version == 0xff {
+		return tp, errors.New("traceparent version ff is invalid")
+	}
+	if tp.Version == 0 && len(s) != 55 {
+		return tp, errors.New("traceparent version 00 has trailing data")
+	}
+	if len(s) > 55 && s[55] != '-' {
+		return tp, errors.New("traceparent is malformed")
+	}
+
+	traceID, err := decodeHex(s[3:35], 16)
+	if err != nil {
+		return tp, fmt.Errorf("parsing trace id: %w", err)
+	}
+	copy(tp.TraceID[:], traceID)
+
+	parentID, err := decodeHex(s[36:52], 8)
+	if err != nil {
+		return tp, fmt.Errorf("parsing parent id: %w", err)
+	}
+	copy(tp.ParentID[:], parentID)
+
+	flags, err := decodeHex(s[53:55], 1)
+	if err != nil {
+		return tp, fmt.Errorf("parsing trace flags: %w", err)
+	}
+	tp.Flags = flags[0]
+
+	if !tp.IsValid() {
+		return tp, errors.New("traceparent has an all zero trace or parent id")
+	}
+	return tp, nil
+}
+
+// decodeHex decodes lower case hex, as required by the specification.
+func decodeHex(s string, n int) ([]byte, error) {
+	if s != strings.ToLower(s) {
+		return nil, fmt.Errorf("%q is not lower case hex", s)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != n {
+		return nil, fmt.Errorf("%q has length %d, expected %d", s, len(b), n)
+	}
+	return b, nil
+}
+
+// TraceState is the parsed form of the tracestate header, a list of vendor
+// specific key/value pairs, most recently updated first.
+type TraceState []TraceStateMember
+
+// TraceStateMember is a single tracestate entry.
+type TraceStateMember struct {
+	Key   string
+	Value string
+}
+
+// maxTraceStateMembers is the limit defined by the specification.
+const maxTraceStateMembers = 32
+
+// ParseTraceState parses a tracestate header value. Empty list members are
+// skipped.
+func ParseTraceState(s string) (TraceState, error) {
+	var ts TraceState
+	seen := make(map[string]bool)
+	for _, member := range strings.Split(s, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(member, "=")
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("tracestate member %q is malformed", member)
+		}
+		if seen[k] {
+			return nil, fmt.Errorf("tracestate key %q is duplicated", k)
+		}
+		seen[k] = true
+		ts = append(ts, TraceStateMember{Key: k, Value: v})
+	}
+	if len(ts) > maxTraceStateMembers {
+		return nil, fmt.Errorf("tracestate has %d members, the limit is %d", len(ts), maxTraceStateMembers)
+	}
+	return ts, nil
+}
+
+// Get returns the value for key, if any.
+func (ts TraceState) Get(key string) (string, bool) {
+	for _, m := range ts {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set returns a copy of ts with key set to value and moved to the front, as
+// the specification requires for updated entries.
+func (ts TraceState) Set(key, value string) TraceState {
+	out := make(TraceState, 0, len(ts)+1)
+	out = append(out, TraceStateMember{Key: key, Value: value})
+	for _, m := range ts {
+		if m.Key != key {
+			out = append(out, m)
+		}
+	}
+	if len(out) > maxTraceStateMembers {
+		out = out[:maxTraceStateMembers]
+	}
+	return out
+}
+
+// String formats the tracestate header value.
+func (ts TraceState) String() string {
+	members := make([]string, len(ts))
+	for i, m := range ts {
+		members[i] = m.Key + "=" + m.Value
+	}
+	return strings.Join(members, ",")
+}
+
+// TraceContext holds the trace context of a request.
+type TraceContext struct {
+	Parent TraceParent
+	State  TraceState
+}
+
+// Use a private type to prevent name collisions with other packages.
+type key string
+
+const (
+	traceContextKey key = "traceContext"
+	baggageKey      key = "baggage"
+)
+
+// NewContext creates a new context with the trace context set to tc.
+func NewContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey, tc)
+}
+
+// FromContext returns the trace context stored in ctx, if any.
+func FromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey).(TraceContext)
+	return tc, ok
+}
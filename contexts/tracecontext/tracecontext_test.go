@@ -0,0 +1,217 @@
+package tracecontext
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kolide/kit/contexts/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name      string
+		in        string
+		expectErr bool
+	}{
+		{name: "valid", in: testTraceParent},
+		{name: "not sampled", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "future version with extra fields", in: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what"},
+		{name: "empty", in: "", expectErr: true},
+		{name: "version ff", in: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectErr: true},
+		{name: "version 00 with extra fields", in: testTraceParent + "-what", expectErr: true},
+		{name: "upper case", in: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", expectErr: true},
+		{name: "zero trace id", in: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", expectErr: true},
+		{name: "zero parent id", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", expectErr: true},
+		{name: "bad separator", in: "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectErr: true},
+		{name: "not hex", in: "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tp, err := ParseTraceParent(tt.in)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tp.IsValid())
+		})
+	}
+
+	tp, err := ParseTraceParent(testTraceParent)
+	require.NoError(t, err)
+	require.Equal(t, testTraceParent, tp.String())
+	require.True(t, tp.Sampled())
+	require.Equal(t, "4bf92f35-77b3-4da6-a3ce-929d0e0e4736", tp.TraceID.UUID())
+}
+
+func TestNewAndChild(t *testing.T) {
+	t.Parallel()
+
+	tp, err := New()
+	require.NoError(t, err)
+	require.True(t, tp.IsValid())
+	require.True(t, tp.Sampled())
+
+	parsed, err := ParseTraceParent(tp.String())
+	require.NoError(t, err)
+	require.Equal(t, tp, parsed)
+
+	child, err := tp.Child()
+	require.NoError(t, err)
+	require.Equal(t, tp.TraceID, child.TraceID)
+	require.NotEqual(t, tp.ParentID, child.ParentID)
+	require.Equal(t, tp.Flags, child.Flags)
+}
+
+func TestTraceState(t *testing.T) {
+	t.Parallel()
+
+	ts, err := ParseTraceState("rojo=00f067aa0ba902b7, ,congo=t61rcWkgMzE")
+	require.NoError(t, err)
+	require.Len(t, ts, 2)
+	v, ok := ts.Get("congo")
+	require.True(t, ok)
+	require.Equal(t, "t61rcWkgMzE", v)
+
+	ts = ts.Set("congo", "updated")
+	require.Equal(t, "congo=updated,rojo=00f067aa0ba902b7", ts.String())
+
+	_, err = ParseTraceState("rojo=1,rojo=2")
+	require.Error(t, err)
+	_, err = ParseTraceState("rojo")
+	require.Error(t, err)
+}
+
+func TestBaggage(t *testing.T) {
+	t.Parallel()
+
+	b, err := ParseBaggage("userId=alice, serverNode=DF%2028;prop=1,isProduction=false")
+	require.NoError(t, err)
+	require.Len(t, b, 3)
+	v, ok := b.Get("serverNode")
+	require.True(t, ok)
+	require.Equal(t, "DF 28", v)
+	require.Equal(t, []string{"prop=1"}, b[1].Properties)
+
+	b = b.Set("userId", "bob smith")
+	require.Equal(t, "serverNode=DF%2028;prop=1,isProduction=false,userId=bob%20smith", b.String())
+
+	_, err = ParseBaggage("=value")
+	require.Error(t, err)
+	_, err = ParseBaggage("key=%zz")
+	require.Error(t, err)
+}
+
+func TestHTTP(t *testing.T) {
+	t.Parallel()
+
+	p := NewPropagator(WithDerivedUUID())
+
+	var (
+		serverTrace   TraceContext
+		serverBaggage Baggage
+		serverUUID    string
+	)
+	srv := httptest.NewServer(p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverTrace, _ = FromContext(r.Context())
+		serverBaggage, _ = BaggageFromContext(r.Context())
+		serverUUID, _ = uuid.FromContext(r.Context())
+	})))
+	t.Cleanup(srv.Close)
+
+	tp, err := ParseTraceParent(testTraceParent)
+	require.NoError(t, err)
+	ctx := NewContext(t.Context(), TraceContext{Parent: tp, State: TraceState{{Key: "rojo", Value: "1"}}})
+	ctx = NewBaggageContext(ctx, Baggage{{Key: "tenant", Value: "acme"}})
+
+	client := &http.Client{Transport: p.NewTransport(nil)}
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Empty(t, req.Header.Get(TraceParentHeader), "the original request must not be modified")
+
+	// the server continues the caller's trace with its own span
+	require.Equal(t, tp.TraceID, serverTrace.Parent.TraceID)
+	require.NotEqual(t, tp.ParentID, serverTrace.Parent.ParentID)
+	require.Equal(t, "rojo=1", serverTrace.State.String())
+	require.Equal(t, "acme", serverBaggage[0].Value)
+	require.Equal(t, tp.TraceID.UUID(), serverUUID)
+
+	// the server starts a new trace when the request has none
+	req, err = http.NewRequestWithContext(t.Context(), "GET", srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(TraceParentHeader, "invalid")
+	req.Header.Set(TraceStateHeader, "rojo=1")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.True(t, serverTrace.Parent.IsValid())
+	require.NotEqual(t, tp.TraceID, serverTrace.Parent.TraceID)
+	require.Empty(t, serverTrace.State, "tracestate is dropped with an invalid traceparent")
+	require.Equal(t, serverTrace.Parent.TraceID.UUID(), serverUUID)
+}
+
+func TestExtractWithoutNewTraces(t *testing.T) {
+	t.Parallel()
+
+	p := NewPropagator(WithoutNewTraces(), WithDerivedUUID())
+	ctx := p.ExtractHTTP(uuid.NewContext(context.Background(), "abc-123"), http.Header{})
+	_, ok := FromContext(ctx)
+	require.False(t, ok)
+
+	h := http.Header{}
+	h.Set(TraceParentHeader, testTraceParent)
+	ctx = p.ExtractHTTP(uuid.NewContext(context.Background(), "abc-123"), h)
+	_, ok = FromContext(ctx)
+	require.True(t, ok)
+	id, _ := uuid.FromContext(ctx)
+	require.Equal(t, "abc-123", id, "an existing request UUID is kept")
+}
+
+func TestGRPC(t *testing.T) {
+	t.Parallel()
+
+	p := NewPropagator()
+	tp, err := ParseTraceParent(testTraceParent)
+	require.NoError(t, err)
+	ctx := NewContext(context.Background(), TraceContext{Parent: tp})
+	ctx = NewBaggageContext(ctx, Baggage{{Key: "tenant", Value: "acme"}})
+
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	require.NoError(t, p.UnaryClientInterceptor()(ctx, "/test", nil, nil, nil, invoker))
+	require.Equal(t, []string{testTraceParent}, outgoing.Get(TraceParentHeader))
+	require.Equal(t, []string{"tenant=acme"}, outgoing.Get(BaggageHeader))
+
+	var serverCtx context.Context
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		serverCtx = ctx
+		return nil, nil
+	}
+	incoming := metadata.NewIncomingContext(context.Background(), outgoing)
+	_, err = p.UnaryServerInterceptor()(incoming, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	tc, ok := FromContext(serverCtx)
+	require.True(t, ok)
+	require.Equal(t, tp.TraceID, tc.Parent.TraceID)
+	b, ok := BaggageFromContext(serverCtx)
+	require.True(t, ok)
+	require.Equal(t, "acme", b[0].Value)
+}
@@ -0,0 +1,58 @@
+package uuid
+
+import (
+	"context"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kolide/kit/contexts/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// grpcHeader is the gRPC metadata key used to propagate the UUID.
+const grpcHeader = "uuid"
+
+// Extract reads the UUID from the gRPC request metadata written by Attach and
+// stores it in the request context. If the request does not carry a UUID, one
+// already stored in the context is kept, otherwise a new UUID is generated.
+func Extract() grpctransport.ServerOption {
+	return grpctransport.ServerBefore(
+		func(ctx context.Context, md metadata.MD) context.Context {
+			return fromMetadata(ctx, md)
+		},
+	)
+}
+
+// UnaryServerInterceptor is the native gRPC equivalent of Extract for unary
+// RPCs.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return handler(fromMetadata(ctx, md), req)
+	}
+}
+
+// StreamServerInterceptor is the native gRPC equivalent of Extract for
+// streaming RPCs.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		return handler(srv, transport.ServerStream(ss, fromMetadata(ss.Context(), md)))
+	}
+}
+
+// fromMetadata stores the UUID found in md in ctx, falling back to the UUID
+// already in ctx and then to a new UUID.
+func fromMetadata(ctx context.Context, md metadata.MD) context.Context {
+	var id string
+	if vals := md.Get(grpcHeader); len(vals) > 0 {
+		id = vals[0]
+	}
+	if id == "" {
+		id, _ = FromContext(ctx)
+	}
+	if id == "" {
+		id = NewForRequest()
+	}
+	return NewContext(ctx, id)
+}
@@ -0,0 +1,45 @@
+package uuid
+
+import (
+	"net/http"
+
+	"github.com/kolide/kit/contexts/internal/transport"
+)
+
+// HTTPHeader is the HTTP header used to propagate the UUID.
+const HTTPHeader = "X-Request-Id"
+
+// Middleware reads the UUID from the X-Request-Id request header and stores
+// it in the request context. If the header is missing, a UUID already stored
+// in the context is kept, otherwise a new UUID is generated.
+// The UUID is also set on the response, so clients can report it.
+// Middleware can be used with httputil.Chain.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HTTPHeader)
+		if id == "" {
+			id, _ = FromContext(r.Context())
+		}
+		if id == "" {
+			id = NewForRequest()
+		}
+		w.Header().Set(HTTPHeader, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// NewTransport returns an http.RoundTripper which sets the X-Request-Id
+// header from the UUID stored in the request context before passing the
+// request to next. If next is nil, http.DefaultTransport is used.
+func NewTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return transport.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		h := make(http.Header)
+		if id, ok := FromContext(r.Context()); ok && id != "" && r.Header.Get(HTTPHeader) == "" {
+			h.Set(HTTPHeader, id)
+		}
+		return next.RoundTrip(transport.WithHeaders(r, h))
+	})
+}
@@ -40,7 +40,7 @@ func Attach() grpctransport.ClientOption {
 	return grpctransport.ClientBefore(
 		func(ctx context.Context, md *metadata.MD) context.Context {
 			uuid, _ := FromContext(ctx)
-			return grpctransport.SetRequestHeader("uuid", uuid)(ctx, md)
+			return grpctransport.SetRequestHeader(grpcHeader, uuid)(ctx, md)
 		},
 	)
 }
@@ -0,0 +1,123 @@
+package uuid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGRPCServerInterceptors(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name string
+		md   metadata.MD
+		want string
+	}{
+		{name: "header", md: metadata.Pairs("uuid", "abc-123"), want: "abc-123"},
+		{name: "generated", md: metadata.MD{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+
+			var unaryID string
+			_, err := UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					unaryID, _ = FromContext(ctx)
+					return nil, nil
+				})
+			require.NoError(t, err)
+
+			var streamID string
+			err = StreamServerInterceptor()(nil, &testStream{ctx: ctx}, &grpc.StreamServerInfo{},
+				func(srv interface{}, ss grpc.ServerStream) error {
+					streamID, _ = FromContext(ss.Context())
+					return nil
+				})
+			require.NoError(t, err)
+
+			require.NotEmpty(t, unaryID)
+			require.NotEmpty(t, streamID)
+			if tt.want != "" {
+				require.Equal(t, tt.want, unaryID)
+				require.Equal(t, tt.want, streamID)
+			}
+		})
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context { return s.ctx }
+
+func TestAttachExtract(t *testing.T) {
+	t.Parallel()
+
+	// Attach and Extract are go-kit options, so exercise the request funcs
+	// they wrap.
+	require.NotNil(t, Attach())
+	require.NotNil(t, Extract())
+
+	md := metadata.MD{}
+	ctx := NewContext(context.Background(), "abc-123")
+	grpctransport.SetRequestHeader(grpcHeader, "abc-123")(ctx, &md)
+
+	id, ok := FromContext(fromMetadata(context.Background(), md))
+	require.True(t, ok)
+	require.Equal(t, "abc-123", id)
+}
+
+func TestHTTP(t *testing.T) {
+	t.Parallel()
+
+	var serverID string
+	srv := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverID, _ = FromContext(r.Context())
+	})))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+
+	// the client propagates the UUID from the context
+	req, err := http.NewRequestWithContext(NewContext(t.Context(), "abc-123"), "GET", srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "abc-123", serverID)
+	require.Equal(t, "abc-123", resp.Header.Get(HTTPHeader))
+	require.Empty(t, req.Header.Get(HTTPHeader), "the original request must not be modified")
+
+	// the server generates a UUID when the request has none
+	req, err = http.NewRequestWithContext(t.Context(), "GET", srv.URL, nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NotEmpty(t, serverID)
+	require.NotEqual(t, "abc-123", serverID)
+	require.Equal(t, serverID, resp.Header.Get(HTTPHeader))
+
+	// a UUID already in the request context is kept when the request has none
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverID, _ = FromContext(r.Context())
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil).WithContext(NewContext(t.Context(), "from-context")))
+	require.Equal(t, "from-context", serverID)
+	require.Equal(t, "from-context", rr.Header().Get(HTTPHeader))
+}
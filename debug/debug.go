@@ -12,6 +12,7 @@ import (
 	"github.com/alecthomas/template"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/kolide/kit/logutil"
 	"github.com/pkg/errors"
 )
 
@@ -22,6 +23,7 @@ type Server struct {
 	authToken string
 	logger    log.Logger
 	prefix    string
+	logs      *logutil.RingBuffer
 }
 
 // Option is the functional option type for Server.
@@ -48,6 +50,13 @@ func WithLogger(logger log.Logger) Option {
 	}
 }
 
+// WithRecentLogs serves the records held by buf under the "logs" path.
+func WithRecentLogs(buf *logutil.RingBuffer) Option {
+	return func(s *Server) {
+		s.logs = buf
+	}
+}
+
 // WithPrefix sets the URL prefix to use.
 func WithPrefix(prefix string) Option {
 	return func(s *Server) {
@@ -69,9 +78,13 @@ func StartServer(opts ...Option) (*Server, error) {
 	}
 
 	m := http.NewServeMux()
-	h := handler(s.authToken, s.logger)
+	var logs http.Handler
+	if s.logs != nil {
+		logs = s.logs.Handler()
+	}
+	h := handler(s.authToken, s.logger, logs)
 	if s.authToken != "" {
-		h = authHandler(s.authToken, s.logger)
+		h = authHandler(s.authToken, s.logger, logs)
 	}
 	m.Handle(s.prefix, http.StripPrefix(s.prefix, h))
 	s.serv = &http.Server{
@@ -116,13 +129,15 @@ func (s *Server) Shutdown() error {
 }
 
 // The below handler code is adapted from MIT licensed github.com/e-dard/netbug
-func handler(token string, logger log.Logger) http.HandlerFunc {
+func handler(token string, logger log.Logger, logs http.Handler) http.HandlerFunc {
 	info := struct {
 		Profiles []*pprof.Profile
 		Token    string
+		Logs     bool
 	}{
 		Profiles: pprof.Profiles(),
 		Token:    url.QueryEscape(token),
+		Logs:     logs != nil,
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -145,6 +160,12 @@ func handler(token string, logger log.Logger) http.HandlerFunc {
 			nhpprof.Trace(w, r)
 		case "symbol":
 			nhpprof.Symbol(w, r)
+		case "logs":
+			if logs == nil {
+				http.NotFound(w, r)
+				return
+			}
+			logs.ServeHTTP(w, r)
 		default:
 			// Provides access to all profiles under runtime/pprof
 			nhpprof.Handler(name).ServeHTTP(w, r)
@@ -153,10 +174,10 @@ func handler(token string, logger log.Logger) http.HandlerFunc {
 }
 
 // authHandler wraps the basic handler, checking the auth token.
-func authHandler(token string, logger log.Logger) http.HandlerFunc {
+func authHandler(token string, logger log.Logger, logs http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.FormValue("token") == token {
-			handler(token, logger).ServeHTTP(w, r)
+			handler(token, logger, logs).ServeHTTP(w, r)
 		} else {
 			http.Error(w, "Request must include valid token.", http.StatusUnauthorized)
 		}
@@ -184,6 +205,10 @@ var indexTmpl = template.Must(template.New("index").Parse(`<html>
       <tr><td align=right><td><a href="cmdline?token={{.Token}}">cmdline</a>
       <tr><td align=right><td><a href="symbol?token={{.Token}}">symbol</a>
     <tr><td align=right><td><a href="goroutine?debug=2&token={{.Token}}">full goroutine stack dump</a><br>
+    {{if .Logs}}
+      <tr><td align=right><td><a href="logs?token={{.Token}}">recent logs</a>
+      <tr><td align=right><td><a href="logs?stream=true&token={{.Token}}">stream logs</a>
+    {{end}}
     <table>
   </body>
 </html>`))
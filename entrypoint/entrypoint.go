@@ -9,23 +9,134 @@ binary with some initial setup.
 package entrypoint
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
 	"syscall"
+	"time"
 )
 
-func Exec() {
+type config struct {
+	fileEnv      bool
+	fileEnvNames []string
+	templates    []templateSpec
+	waits        []waitSpec
+	backoff      backoff
+	hooks        []hookSpec
+	user         string
+	chown        []string
+	noNewPrivs   bool
+}
+
+// Option configures the setup performed by Exec before the target binary
+// is executed.
+type Option func(*config)
+
+// Exec replaces the current process with the binary named by the first
+// argument, after performing the setup configured by opts.
+// If there are no arguments Exec returns without doing anything.
+func Exec(opts ...Option) {
+	// no_new_privs is set per thread, so prepare and exec must run on the
+	// same one.
+	runtime.LockOSThread()
+
 	flag.Parse()
 	if len(os.Args) == 1 {
 		return
 	}
+
+	cmd := prepare(opts)
+	if err := syscall.Exec(cmd, flag.Args(), os.Environ()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// prepare performs the setup configured by opts and returns the path of the
+// target binary. It exits the process if any step fails.
+//
+// Setup happens in the following order: secrets are read from files,
+// templates are rendered, dependencies are waited for, hooks are run and
+// finally privileges are dropped.
+func prepare(opts []Option) string {
+	c := config{
+		backoff: backoff{initial: 100 * time.Millisecond, max: 5 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.fileEnv {
+		if err := ExpandFileEnv(c.fileEnvNames...); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, t := range c.templates {
+		if err := RenderTemplates(t.srcGlob, t.destDir, environ()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, w := range c.waits {
+		if err := waitFor(context.Background(), log.Default(), w, c.backoff); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, h := range c.hooks {
+		if err := runHooks(context.Background(), log.Default(), h); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := dropToUser(c); err != nil {
+		log.Fatal(err)
+	}
+
 	cmd, err := exec.LookPath(os.Args[1])
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := syscall.Exec(cmd, flag.Args(), os.Environ()); err != nil {
-		log.Fatal(err)
+	return cmd
+}
+
+// dropToUser changes the owner of the configured directories, switches to
+// the configured user and sets no_new_privs.
+func dropToUser(c config) error {
+	if c.user != "" {
+		creds, err := lookupCredentials(c.user)
+		if err != nil {
+			return err
+		}
+		for _, dir := range c.chown {
+			if err := chownAll(dir, creds.uid, creds.gid); err != nil {
+				return err
+			}
+		}
+		if err := dropPrivileges(creds); err != nil {
+			return err
+		}
+	}
+	if c.noNewPrivs {
+		if err := setNoNewPrivs(); err != nil {
+			return fmt.Errorf("setting no_new_privs: %w", err)
+		}
+	}
+	return nil
+}
+
+// environ returns the environment of the current process as a map.
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
 	}
+	return env
 }
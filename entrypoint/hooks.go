@@ -0,0 +1,75 @@
+package entrypoint
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+type hookSpec struct {
+	dir     string
+	timeout time.Duration
+}
+
+// WithHookDir runs the executable files in dir, in lexical order, before the
+// target binary is executed, like the scripts in /docker-entrypoint.d.
+// Each hook must exit successfully within timeout, otherwise Exec exits with
+// a non-zero status. Files which are not executable are skipped, as is a
+// missing dir.
+func WithHookDir(dir string, timeout time.Duration) Option {
+	return func(c *config) {
+		c.hooks = append(c.hooks, hookSpec{dir: dir, timeout: timeout})
+	}
+}
+
+// runHooks runs the executable files in h.dir in lexical order.
+func runHooks(ctx context.Context, logger *log.Logger, h hookSpec) error {
+	entries, err := os.ReadDir(h.dir)
+	if os.IsNotExist(err) {
+		logger.Printf("hook directory %s does not exist, skipping", h.dir)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading hook directory %s: %w", h.dir, err)
+	}
+
+	// os.ReadDir returns entries sorted by filename.
+	for _, entry := range entries {
+		path := filepath.Join(h.dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat hook %s: %w", path, err)
+		}
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			logger.Printf("skipping hook %s, not an executable file", path)
+			continue
+		}
+
+		logger.Printf("running hook %s", path)
+		if err := runHook(ctx, path, h.timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, path string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("hook %s timed out after %v", path, timeout)
+		}
+		return fmt.Errorf("running hook %s: %w", path, err)
+	}
+	return nil
+}
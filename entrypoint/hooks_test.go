@@ -0,0 +1,91 @@
+//go:build !windows
+// +build !windows
+
+package entrypoint
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHooks(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "out")
+
+	var tests = []struct {
+		name    string
+		hooks   map[string]string
+		want    string
+		errMsg  string
+		timeout time.Duration
+	}{
+		{
+			name: "lexical order",
+			hooks: map[string]string{
+				"20-second.sh": "echo second >> " + out + "-order",
+				"10-first.sh":  "echo first >> " + out + "-order",
+			},
+			want: "first\nsecond\n",
+		},
+		{
+			name:   "failure",
+			hooks:  map[string]string{"10-fail.sh": "exit 1"},
+			errMsg: "running hook",
+		},
+		{
+			name:    "timeout",
+			hooks:   map[string]string{"10-slow.sh": "exec sleep 5"},
+			timeout: 50 * time.Millisecond,
+			errMsg:  "timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for name, script := range tt.hooks {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755))
+			}
+			// not executable, must be skipped
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "00-readme"), []byte("exit 1"), 0644))
+
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			var buf bytes.Buffer
+			err := runHooks(context.Background(), log.New(&buf, "", 0), hookSpec{dir: dir, timeout: timeout})
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				require.True(t, strings.Contains(err.Error(), tt.errMsg), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, strings.Contains(buf.String(), "skipping hook"))
+
+			got, err := os.ReadFile(out + "-order")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestRunHooksMissingDir(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	hooks := hookSpec{dir: filepath.Join(t.TempDir(), "missing"), timeout: time.Second}
+	require.NoError(t, runHooks(context.Background(), log.New(&buf, "", 0), hooks))
+}
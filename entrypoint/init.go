@@ -0,0 +1,132 @@
+//go:build !windows
+// +build !windows
+
+package entrypoint
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Init is an alternative to Exec for containers where the entrypoint runs as
+// PID 1. Instead of replacing the current process, Init starts the target
+// binary as a child in its own process group and stays running as an init
+// process: it forwards every catchable signal to the child's process group,
+// reaps orphaned zombie processes, and exits with the child's exit code, or
+// 128 plus the signal number if the child was killed by a signal.
+// If stdin is a controlling terminal, the child's process group is also made
+// the terminal's foreground group, so that it can read from the terminal
+// without being stopped by SIGTTIN. Signals generated by the terminal, such as
+// SIGINT from Ctrl-C, are then delivered to the child directly.
+// If there are no arguments Init returns without doing anything.
+func Init(opts ...Option) {
+	// no_new_privs is set per thread and inherited by children forked from
+	// it, so prepare and the fork in runChild must run on the same one.
+	runtime.LockOSThread()
+
+	flag.Parse()
+	if len(os.Args) == 1 {
+		return
+	}
+
+	cmd := prepare(opts)
+	code, err := runChild(cmd, flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(code)
+}
+
+// runChild starts path as a child process and supervises it until it exits,
+// returning its exit code.
+func runChild(path string, args []string) (int, error) {
+	// subscribe before starting the child, so that neither signals nor the
+	// child's exit can be missed. signal.Notify drops signals when a channel
+	// is full, so SIGCHLD gets its own channel where a burst of forwarded
+	// signals can't crowd it out. One pending SIGCHLD is enough, since reap
+	// collects every exited child.
+	children := make(chan os.Signal, 1)
+	signal.Notify(children, syscall.SIGCHLD)
+	defer signal.Stop(children)
+	sigs := make(chan os.Signal, 32)
+	signal.Notify(sigs)
+	defer signal.Stop(sigs)
+
+	cmd := &exec.Cmd{
+		Path:        path,
+		Args:        args,
+		Env:         os.Environ(),
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		SysProcAttr: childProcAttr(os.Stdin),
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+
+	for {
+		select {
+		case <-children:
+			if code, exited := reap(pid); exited {
+				return code, nil
+			}
+		case sig := <-sigs:
+			switch sig {
+			case syscall.SIGCHLD:
+				// handled through children
+			case syscall.SIGURG:
+				// used internally by the Go runtime for goroutine preemption
+			default:
+				if s, ok := sig.(syscall.Signal); ok {
+					syscall.Kill(-pid, s)
+				}
+			}
+		}
+	}
+}
+
+// childProcAttr starts the child in its own process group. A background
+// process group cannot read from its controlling terminal, so if stdin is
+// one, the child's group is placed in the foreground.
+func childProcAttr(stdin *os.File) *syscall.SysProcAttr {
+	fd := int(stdin.Fd())
+	if _, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP); err == nil {
+		return &syscall.SysProcAttr{Foreground: true, Ctty: fd}
+	}
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// reap waits for every exited child without blocking. It reports the exit
+// code of pid if it was among them.
+func reap(pid int) (code int, exited bool) {
+	for {
+		var ws syscall.WaitStatus
+		wpid, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || wpid <= 0 {
+			return code, exited
+		}
+		if wpid == pid {
+			exited = true
+			code = exitCode(ws)
+		}
+	}
+}
+
+func exitCode(ws syscall.WaitStatus) int {
+	if ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return ws.ExitStatus()
+}
@@ -0,0 +1,108 @@
+//go:build !windows
+// +build !windows
+
+package entrypoint
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// runChild reaps every child of the test process, so these tests don't run
+// in parallel.
+func TestRunChild(t *testing.T) {
+	// keep a late signal from killing the test binary if runChild returns
+	// before it is sent.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	defer signal.Stop(sigs)
+
+	var tests = []struct {
+		name   string
+		script string
+		signal syscall.Signal
+		burst  bool
+		want   int
+	}{
+		{
+			name:   "exit code",
+			script: "exit 3",
+			want:   3,
+		},
+		{
+			name:   "killed by signal",
+			script: "kill -TERM $$",
+			want:   128 + int(syscall.SIGTERM),
+		},
+		{
+			name:   "forwarded signal",
+			script: `trap "exit 7" USR1; while true; do sleep 0.01; done`,
+			signal: syscall.SIGUSR1,
+			want:   7,
+		},
+		{
+			name:   "exit during a signal burst",
+			script: `trap "" WINCH; sleep 0.2; exit 5`,
+			signal: syscall.SIGWINCH,
+			burst:  true,
+			want:   5,
+		},
+		{
+			name:   "reaps orphans",
+			script: "sleep 0.01 & exit 0",
+			want:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.burst {
+				done := make(chan struct{})
+				defer close(done)
+				go func() {
+					for {
+						select {
+						case <-done:
+							return
+						default:
+							syscall.Kill(os.Getpid(), tt.signal)
+						}
+					}
+				}()
+			} else if tt.signal != 0 {
+				go func() {
+					// give the shell time to install its trap
+					time.Sleep(200 * time.Millisecond)
+					syscall.Kill(os.Getpid(), tt.signal)
+				}()
+			}
+
+			code, err := runChild("/bin/sh", []string{"sh", "-c", tt.script})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestChildProcAttr(t *testing.T) {
+	t.Parallel()
+
+	devnull, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	t.Cleanup(func() { devnull.Close() })
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close(); w.Close() })
+
+	for _, f := range []*os.File{devnull, r} {
+		attr := childProcAttr(f)
+		require.True(t, attr.Setpgid, f.Name())
+		require.False(t, attr.Foreground, f.Name())
+	}
+}
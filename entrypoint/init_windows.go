@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package entrypoint
+
+import "log"
+
+// Init is not supported on Windows.
+func Init(opts ...Option) {
+	log.Fatal("entrypoint: init mode is not supported on windows")
+}
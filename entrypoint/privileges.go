@@ -0,0 +1,134 @@
+package entrypoint
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// WithUser switches to the given user before the target binary is executed,
+// replacing tools like gosu and su-exec. spec has the form "user",
+// "user:group", "uid" or "uid:gid", where user and group are names or
+// numeric IDs. The supplementary groups of the user are set from the group
+// database, and HOME is set to the user's home directory.
+// If no group is given, the user's primary group is used.
+func WithUser(spec string) Option {
+	return func(c *config) {
+		c.user = spec
+	}
+}
+
+// WithChown recursively changes the owner of each of dirs to the user set by
+// WithUser before privileges are dropped, so the target can write to them.
+func WithChown(dirs ...string) Option {
+	return func(c *config) {
+		c.chown = append(c.chown, dirs...)
+	}
+}
+
+// WithNoNewPrivs sets the no_new_privs flag before the target binary is
+// executed, so neither it nor its children can gain privileges through
+// setuid binaries or file capabilities. It is only supported on Linux.
+func WithNoNewPrivs() Option {
+	return func(c *config) {
+		c.noNewPrivs = true
+	}
+}
+
+// credentials identify the user the target binary runs as.
+type credentials struct {
+	uid    int
+	gid    int
+	groups []int
+	home   string
+}
+
+// lookupCredentials resolves a "user:group" spec to numeric IDs.
+func lookupCredentials(spec string) (credentials, error) {
+	userPart, groupPart, hasGroup := strings.Cut(spec, ":")
+	if userPart == "" {
+		return credentials{}, fmt.Errorf("invalid user %q", spec)
+	}
+
+	var creds credentials
+	u, err := lookupUser(userPart)
+	switch {
+	case err == nil:
+		if creds.uid, err = strconv.Atoi(u.Uid); err != nil {
+			return creds, fmt.Errorf("parsing uid of user %s: %w", userPart, err)
+		}
+		if creds.gid, err = strconv.Atoi(u.Gid); err != nil {
+			return creds, fmt.Errorf("parsing gid of user %s: %w", userPart, err)
+		}
+		creds.home = u.HomeDir
+		if creds.groups, err = supplementaryGroups(u); err != nil {
+			return creds, err
+		}
+	case isNumeric(userPart):
+		// numeric IDs don't need to exist in the user database
+		creds.uid, _ = strconv.Atoi(userPart)
+		creds.gid = creds.uid
+		creds.home = "/"
+	default:
+		return creds, fmt.Errorf("looking up user %s: %w", userPart, err)
+	}
+
+	if hasGroup {
+		gid, err := lookupGroup(groupPart)
+		if err != nil {
+			return creds, err
+		}
+		creds.gid = gid
+		// an explicit group replaces the user's supplementary groups
+		creds.groups = []int{gid}
+	}
+	if len(creds.groups) == 0 {
+		creds.groups = []int{creds.gid}
+	}
+	return creds, nil
+}
+
+func lookupUser(name string) (*user.User, error) {
+	if isNumeric(name) {
+		return user.LookupId(name)
+	}
+	return user.Lookup(name)
+}
+
+func lookupGroup(name string) (int, error) {
+	if isNumeric(name) {
+		gid, _ := strconv.Atoi(name)
+		return gid, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("looking up group %s: %w", name, err)
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return 0, fmt.Errorf("parsing gid of group %s: %w", name, err)
+	}
+	return gid, nil
+}
+
+func supplementaryGroups(u *user.User) ([]int, error) {
+	ids, err := u.GroupIds()
+	if err != nil {
+		return nil, fmt.Errorf("looking up groups of user %s: %w", u.Username, err)
+	}
+	groups := make([]int, 0, len(ids))
+	for _, id := range ids {
+		gid, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("parsing group id %s: %w", id, err)
+		}
+		groups = append(groups, gid)
+	}
+	return groups, nil
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil && !strings.HasPrefix(s, "-")
+}
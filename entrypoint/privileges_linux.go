@@ -0,0 +1,18 @@
+//go:build linux
+// +build linux
+
+package entrypoint
+
+import "syscall"
+
+// prSetNoNewPrivs is PR_SET_NO_NEW_PRIVS from linux/prctl.h.
+const prSetNoNewPrivs = 38
+
+// setNoNewPrivs sets no_new_privs on the calling thread only. The caller must
+// be locked to its OS thread and exec or fork from it.
+func setNoNewPrivs() error {
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
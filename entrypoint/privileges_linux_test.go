@@ -0,0 +1,28 @@
+//go:build linux
+// +build linux
+
+package entrypoint
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecNoNewPrivs(t *testing.T) {
+	if os.Getenv("KIT_TEST_EXEC_HELPER") == "1" {
+		os.Args = []string{os.Args[0], "/bin/sh", "-c", "grep NoNewPrivs /proc/self/status"}
+		Exec(WithNoNewPrivs())
+		t.Fatal("Exec returned")
+	}
+	t.Parallel()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExecNoNewPrivs$")
+	cmd.Env = append(os.Environ(), "KIT_TEST_EXEC_HELPER=1")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.True(t, strings.Contains(string(out), "NoNewPrivs:\t1"), string(out))
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package entrypoint
+
+import "errors"
+
+func setNoNewPrivs() error {
+	return errors.New("no_new_privs is only supported on linux")
+}
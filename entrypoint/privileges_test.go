@@ -0,0 +1,81 @@
+//go:build !windows
+// +build !windows
+
+package entrypoint
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupCredentials(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		spec      string
+		uid       int
+		gid       int
+		groups    []int
+		home      string
+		expectErr bool
+	}{
+		// the groups and home of root differ between platforms
+		{spec: "root", uid: 0, gid: 0},
+		{spec: "0", uid: 0, gid: 0},
+		{spec: "root:0", uid: 0, gid: 0, groups: []int{0}},
+		{spec: "12345", uid: 12345, gid: 12345, groups: []int{12345}, home: "/"},
+		{spec: "12345:54321", uid: 12345, gid: 54321, groups: []int{54321}, home: "/"},
+		{spec: "", expectErr: true},
+		{spec: ":0", expectErr: true},
+		{spec: "-1", expectErr: true},
+		{spec: "kit-no-such-user", expectErr: true},
+		{spec: "root:kit-no-such-group", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+
+			creds, err := lookupCredentials(tt.spec)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.uid, creds.uid)
+			require.Equal(t, tt.gid, creds.gid)
+			require.NotEmpty(t, creds.groups)
+			if tt.groups != nil {
+				require.Equal(t, tt.groups, creds.groups)
+			}
+			if tt.home != "" {
+				require.Equal(t, tt.home, creds.home)
+			}
+		})
+	}
+}
+
+func TestChownAll(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "b", "file"), nil, 0644))
+	require.NoError(t, os.Symlink("/nonexistent", filepath.Join(dir, "a", "link")))
+
+	uid, gid := os.Getuid(), os.Getgid()
+	require.NoError(t, chownAll(dir, uid, gid))
+
+	for _, path := range []string{dir, filepath.Join(dir, "a", "b", "file"), filepath.Join(dir, "a", "link")} {
+		info, err := os.Lstat(path)
+		require.NoError(t, err)
+		stat := info.Sys().(*syscall.Stat_t)
+		require.Equal(t, uint32(uid), stat.Uid, path)
+		require.Equal(t, uint32(gid), stat.Gid, path)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build !windows
+// +build !windows
+
+package entrypoint
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// chownAll recursively changes the owner of dir and its contents.
+// Symbolic links are changed themselves rather than followed.
+func chownAll(dir string, uid, gid int) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return fmt.Errorf("chown %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// dropPrivileges switches the process to creds. The supplementary groups and
+// gid must be changed before the uid, while the process is still allowed to.
+func dropPrivileges(creds credentials) error {
+	if err := syscall.Setgroups(creds.groups); err != nil {
+		return fmt.Errorf("setting supplementary groups: %w", err)
+	}
+	if err := syscall.Setgid(creds.gid); err != nil {
+		return fmt.Errorf("setting gid %d: %w", creds.gid, err)
+	}
+	if err := syscall.Setuid(creds.uid); err != nil {
+		return fmt.Errorf("setting uid %d: %w", creds.uid, err)
+	}
+	if creds.home != "" {
+		if err := os.Setenv("HOME", creds.home); err != nil {
+			return fmt.Errorf("setting HOME: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package entrypoint
+
+import "errors"
+
+var errPrivilegesUnsupported = errors.New("changing users is not supported on windows")
+
+func chownAll(dir string, uid, gid int) error {
+	return errPrivilegesUnsupported
+}
+
+func dropPrivileges(creds credentials) error {
+	return errPrivilegesUnsupported
+}
+
+func setNoNewPrivs() error {
+	return errPrivilegesUnsupported
+}
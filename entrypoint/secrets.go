@@ -0,0 +1,74 @@
+package entrypoint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// WithFileEnv sets environment variables from the contents of the files
+// named by their _FILE counterparts before any other setup is performed,
+// following the convention used by Docker secrets. See ExpandFileEnv for
+// details.
+func WithFileEnv(names ...string) Option {
+	return func(c *config) {
+		c.fileEnv = true
+		c.fileEnvNames = names
+	}
+}
+
+// ExpandFileEnv sets the environment variable VAR from the contents of the
+// file named by VAR_FILE, with a single trailing newline removed. It returns
+// an error if both VAR and VAR_FILE are set, or if a file cannot be read.
+//
+// If names are given, only those variables are expanded and their VAR_FILE
+// variables are unset. Otherwise every variable ending in _FILE is expanded
+// but left set, since the environment may contain unrelated variables like
+// SSL_CERT_FILE that other programs still need. Listing the names is
+// recommended.
+func ExpandFileEnv(names ...string) error {
+	env := environ()
+
+	var fileKeys []string
+	if len(names) > 0 {
+		for _, name := range names {
+			if _, ok := env[name+"_FILE"]; ok {
+				fileKeys = append(fileKeys, name+"_FILE")
+			}
+		}
+	} else {
+		for k := range env {
+			if strings.HasSuffix(k, "_FILE") && k != "_FILE" {
+				fileKeys = append(fileKeys, k)
+			}
+		}
+		// sort the keys so errors are reported deterministically
+		sort.Strings(fileKeys)
+	}
+
+	for _, fileKey := range fileKeys {
+		key := strings.TrimSuffix(fileKey, "_FILE")
+		if _, ok := env[key]; ok {
+			return fmt.Errorf("both %s and %s are set, but they are exclusive", key, fileKey)
+		}
+
+		data, err := os.ReadFile(env[fileKey])
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", fileKey, err)
+		}
+		value := strings.TrimSuffix(string(data), "\n")
+		value = strings.TrimSuffix(value, "\r")
+
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("setting %s: %w", key, err)
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if err := os.Unsetenv(fileKey); err != nil {
+			return fmt.Errorf("unsetting %s: %w", fileKey, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package entrypoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ExpandFileEnv modifies the process environment, so these tests don't run
+// in parallel.
+func TestExpandFileEnv(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(secret, []byte("hunter2\n"), 0600))
+
+	t.Setenv("KIT_TEST_DB_PASSWORD_FILE", secret)
+	t.Setenv("KIT_TEST_UNLISTED_FILE", secret)
+	require.NoError(t, ExpandFileEnv("KIT_TEST_DB_PASSWORD", "KIT_TEST_UNSET"))
+
+	require.Equal(t, "hunter2", os.Getenv("KIT_TEST_DB_PASSWORD"))
+	_, ok := os.LookupEnv("KIT_TEST_DB_PASSWORD_FILE")
+	require.False(t, ok)
+	os.Unsetenv("KIT_TEST_DB_PASSWORD")
+
+	// only the listed variables are expanded
+	_, ok = os.LookupEnv("KIT_TEST_UNLISTED")
+	require.False(t, ok)
+}
+
+func TestExpandFileEnvAll(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(cert, []byte("certificate\n"), 0600))
+
+	// clear any unrelated _FILE variables from the test environment
+	for _, kv := range os.Environ() {
+		if k := strings.SplitN(kv, "=", 2)[0]; strings.HasSuffix(k, "_FILE") {
+			t.Setenv(k, "")
+			os.Unsetenv(k)
+		}
+	}
+	t.Setenv("SSL_CERT_FILE", cert)
+	t.Setenv("SSL_CERT", "")
+	os.Unsetenv("SSL_CERT")
+
+	require.NoError(t, ExpandFileEnv())
+	require.Equal(t, "certificate", os.Getenv("SSL_CERT"))
+
+	// variables that weren't named must stay set for other programs
+	require.Equal(t, cert, os.Getenv("SSL_CERT_FILE"))
+}
+
+func TestExpandFileEnvErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		env  map[string]string
+	}{
+		{
+			name: "conflict",
+			env: map[string]string{
+				"KIT_TEST_TOKEN":      "abc",
+				"KIT_TEST_TOKEN_FILE": "/dev/null",
+			},
+		},
+		{
+			name: "missing file",
+			env: map[string]string{
+				"KIT_TEST_TOKEN_FILE": filepath.Join(t.TempDir(), "missing"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			require.Error(t, ExpandFileEnv("KIT_TEST_TOKEN"))
+		})
+	}
+}
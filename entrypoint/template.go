@@ -0,0 +1,126 @@
+package entrypoint
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/kolide/kit/fsutil"
+)
+
+type templateSpec struct {
+	srcGlob string
+	destDir string
+}
+
+// WithTemplates renders every file matching srcGlob into destDir before the
+// target binary is executed. See RenderTemplates for details.
+func WithTemplates(srcGlob, destDir string) Option {
+	return func(c *config) {
+		c.templates = append(c.templates, templateSpec{srcGlob: srcGlob, destDir: destDir})
+	}
+}
+
+// RenderTemplates renders every file matching srcGlob as a Go text/template
+// into destDir. The rendered file has the same name as the template, without
+// a ".tmpl" suffix, and the same permissions. Files are written atomically,
+// so a process reading them never sees a partial render.
+//
+// Environment variables are available as fields of the template data, like
+// {{ .HOME }}, and the following functions are provided:
+//
+//	env "NAME"               the value of the variable NAME
+//	required "NAME"          the value of NAME, failing if it is unset or empty
+//	default "value" .NAME    .NAME, or "value" if .NAME is empty
+//	split "," .NAME          .NAME split into a list by ","
+//	base64 .NAME             .NAME encoded as standard base64
+//	base64decode .NAME       .NAME decoded from standard base64
+func RenderTemplates(srcGlob, destDir string, env map[string]string) error {
+	matches, err := filepath.Glob(srcGlob)
+	if err != nil {
+		return fmt.Errorf("matching templates %s: %w", srcGlob, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no templates match %s", srcGlob)
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("creating template destination %s: %w", destDir, err)
+	}
+	for _, src := range matches {
+		dest := filepath.Join(destDir, strings.TrimSuffix(filepath.Base(src), ".tmpl"))
+		if err := renderTemplate(src, dest, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderTemplate(src, dest string, env map[string]string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("stat template %s: %w", src, err)
+	}
+	text, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("reading template %s: %w", src, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(src)).
+		Funcs(templateFuncs(env)).
+		Option("missingkey=zero").
+		Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("parsing template %s: %w", src, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, env); err != nil {
+		return fmt.Errorf("rendering template %s: %w", src, err)
+	}
+
+	if err := fsutil.WriteFileAtomic(dest, buf.Bytes(), fsutil.WithMode(info.Mode().Perm())); err != nil {
+		return fmt.Errorf("writing %s: %w", dest, err)
+	}
+	return nil
+}
+
+func templateFuncs(env map[string]string) template.FuncMap {
+	return template.FuncMap{
+		"env": func(name string) string {
+			return env[name]
+		},
+		"required": func(name string) (string, error) {
+			if v := env[name]; v != "" {
+				return v, nil
+			}
+			return "", fmt.Errorf("required environment variable %s is not set", name)
+		},
+		"default": func(def, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		"split": func(sep, value string) []string {
+			if value == "" {
+				return nil
+			}
+			return strings.Split(value, sep)
+		},
+		"base64": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+		"base64decode": func(value string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return "", fmt.Errorf("decoding base64: %w", err)
+			}
+			return string(b), nil
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package entrypoint
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"DB_HOST": "db.internal",
+		"HOSTS":   "a,b,c",
+		"SECRET":  "hunter2",
+		"ENCODED": "aHVudGVyMg==",
+	}
+
+	var tests = []struct {
+		name     string
+		template string
+		want     string
+		errMsg   string
+	}{
+		{
+			name:     "fields",
+			template: "host={{ .DB_HOST }} missing={{ .MISSING }}",
+			want:     "host=db.internal missing=",
+		},
+		{
+			name:     "env",
+			template: `{{ env "DB_HOST" }}`,
+			want:     "db.internal",
+		},
+		{
+			name:     "default",
+			template: `{{ .PORT | default "5432" }} {{ default "x" .DB_HOST }}`,
+			want:     "5432 db.internal",
+		},
+		{
+			name:     "split",
+			template: `{{ range split "," .HOSTS }}[{{ . }}]{{ end }}`,
+			want:     "[a][b][c]",
+		},
+		{
+			name:     "base64",
+			template: `{{ base64 .SECRET }} {{ base64decode .ENCODED }}`,
+			want:     "aHVudGVyMg== hunter2",
+		},
+		{
+			name:     "required",
+			template: `{{ required "DB_HOST" }}`,
+			want:     "db.internal",
+		},
+		{
+			name:     "required missing",
+			template: `{{ required "DB_PASSWORD" }}`,
+			errMsg:   "required environment variable DB_PASSWORD is not set",
+		},
+		{
+			name:     "parse error",
+			template: `{{ .DB_HOST `,
+			errMsg:   "parsing template",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srcDir, destDir := t.TempDir(), filepath.Join(t.TempDir(), "conf")
+			src := filepath.Join(srcDir, "app.conf.tmpl")
+			require.NoError(t, os.WriteFile(src, []byte(tt.template), 0600))
+
+			err := RenderTemplates(filepath.Join(srcDir, "*.tmpl"), destDir, env)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				require.True(t, strings.Contains(err.Error(), tt.errMsg), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			dest := filepath.Join(destDir, "app.conf")
+			rendered, err := os.ReadFile(dest)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(rendered))
+
+			if runtime.GOOS != "windows" {
+				info, err := os.Stat(dest)
+				require.NoError(t, err)
+				require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestRenderTemplatesNoMatch(t *testing.T) {
+	t.Parallel()
+
+	err := RenderTemplates(filepath.Join(t.TempDir(), "*.tmpl"), t.TempDir(), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package entrypoint
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+type waitSpec struct {
+	name    string
+	timeout time.Duration
+	check   func(context.Context) error
+	backoff *backoff
+}
+
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+// minBackoff is the shortest delay between attempts. Smaller initial delays
+// are raised to it so a zero delay cannot busy-loop.
+const minBackoff = 10 * time.Millisecond
+
+// WaitOption configures a single dependency passed to WithWaitTCP,
+// WithWaitHTTP or WithWaitFile.
+type WaitOption func(*waitSpec)
+
+// WithBackoff sets the delay between attempts to reach this dependency,
+// overriding the default set by WithWaitBackoff. The delay starts at initial
+// and doubles after every failed attempt, up to max.
+func WithBackoff(initial, max time.Duration) WaitOption {
+	return func(w *waitSpec) {
+		w.backoff = &backoff{initial: initial, max: max}
+	}
+}
+
+// WithWaitTCP waits until a TCP connection to addr succeeds before the target
+// binary is executed. Exec exits with a non-zero status if addr cannot be
+// reached within timeout.
+func WithWaitTCP(addr string, timeout time.Duration, opts ...WaitOption) Option {
+	return withWait(waitSpec{
+		name:    "tcp " + addr,
+		timeout: timeout,
+		check: func(ctx context.Context) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}, opts)
+}
+
+// WithWaitHTTP waits until a GET request to url returns a 2xx status before
+// the target binary is executed. Exec exits with a non-zero status if no
+// successful response is received within timeout.
+func WithWaitHTTP(url string, timeout time.Duration, opts ...WaitOption) Option {
+	return withWait(waitSpec{
+		name:    "http " + url,
+		timeout: timeout,
+		check: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return fmt.Errorf("unexpected status %s", resp.Status)
+			}
+			return nil
+		},
+	}, opts)
+}
+
+// WithWaitFile waits until path exists before the target binary is executed.
+// Exec exits with a non-zero status if path does not exist within timeout.
+func WithWaitFile(path string, timeout time.Duration, opts ...WaitOption) Option {
+	return withWait(waitSpec{
+		name:    "file " + path,
+		timeout: timeout,
+		check: func(context.Context) error {
+			_, err := os.Stat(path)
+			return err
+		},
+	}, opts)
+}
+
+// WithWaitBackoff sets the default delay between attempts to reach a
+// dependency. The delay starts at initial and doubles after every failed
+// attempt, up to max. The default is 100ms doubling up to 5s. Use WithBackoff
+// to change the delay for a single dependency.
+func WithWaitBackoff(initial, max time.Duration) Option {
+	return func(c *config) {
+		c.backoff = backoff{initial: initial, max: max}
+	}
+}
+
+func withWait(w waitSpec, opts []WaitOption) Option {
+	for _, opt := range opts {
+		opt(&w)
+	}
+	return func(c *config) {
+		c.waits = append(c.waits, w)
+	}
+}
+
+// waitFor checks w until it succeeds, backing off between attempts. The
+// default backoff b is used unless w sets its own. It returns an error if w
+// does not succeed before its timeout.
+func waitFor(ctx context.Context, logger *log.Logger, w waitSpec, b backoff) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	if w.backoff != nil {
+		b = *w.backoff
+	}
+	if b.initial < minBackoff {
+		b.initial = minBackoff
+	}
+	if b.max < b.initial {
+		b.max = b.initial
+	}
+
+	start := time.Now()
+	delay := b.initial
+	for attempt := 1; ; attempt++ {
+		err := w.check(ctx)
+		if err == nil {
+			logger.Printf("%s is available after %v", w.name, time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+		logger.Printf("waiting for %s (attempt %d): %v; retrying in %v", w.name, attempt, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %v waiting for %s: %w", w.timeout, w.name, err)
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > b.max {
+			delay = b.max
+		}
+	}
+}
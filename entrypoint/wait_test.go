@@ -0,0 +1,134 @@
+package entrypoint
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitFor(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0") //nolint:noctx
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fail the first request to exercise the retry
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "ready")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(path, nil, 0644)
+	}()
+
+	var tests = []struct {
+		name   string
+		opt    Option
+		errMsg string
+	}{
+		{
+			name: "tcp",
+			opt:  WithWaitTCP(l.Addr().String(), time.Second),
+		},
+		{
+			name: "http",
+			opt:  WithWaitHTTP(srv.URL, time.Second),
+		},
+		{
+			name: "file",
+			opt:  WithWaitFile(path, time.Second),
+		},
+		{
+			name:   "timeout",
+			opt:    WithWaitFile(filepath.Join(t.TempDir(), "never"), 50*time.Millisecond),
+			errMsg: "timed out after 50ms waiting for file",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var c config
+			tt.opt(&c)
+			WithWaitBackoff(5*time.Millisecond, 20*time.Millisecond)(&c)
+			require.Len(t, c.waits, 1)
+
+			var buf bytes.Buffer
+			err := waitFor(context.Background(), log.New(&buf, "", 0), c.waits[0], c.backoff)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				require.True(t, strings.Contains(err.Error(), tt.errMsg), err.Error())
+				require.True(t, strings.Contains(buf.String(), "waiting for"))
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, strings.Contains(buf.String(), "is available"))
+		})
+	}
+}
+
+func TestWaitForBackoff(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		opts     []WaitOption
+		backoff  backoff
+		maxTries int
+	}{
+		{
+			name:     "zero default is clamped",
+			backoff:  backoff{},
+			maxTries: 10,
+		},
+		{
+			name:     "negative default is clamped",
+			backoff:  backoff{initial: -time.Second, max: -time.Second},
+			maxTries: 10,
+		},
+		{
+			name:     "per dependency backoff overrides default",
+			opts:     []WaitOption{WithBackoff(time.Second, time.Second)},
+			backoff:  backoff{initial: time.Millisecond, max: time.Millisecond},
+			maxTries: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var c config
+			WithWaitFile(filepath.Join(t.TempDir(), "never"), 100*time.Millisecond, tt.opts...)(&c)
+			require.Len(t, c.waits, 1)
+
+			var buf bytes.Buffer
+			err := waitFor(context.Background(), log.New(&buf, "", 0), c.waits[0], tt.backoff)
+			require.Error(t, err)
+
+			tries := strings.Count(buf.String(), "waiting for")
+			require.True(t, tries >= 1 && tries <= tt.maxTries, "made %d attempts", tries)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// AtomicOption configures an AtomicWriter.
+type AtomicOption func(*AtomicWriter)
+
+// WithMode sets the permissions of the written file. The mode is set exactly,
+// it is not modified by the umask. By default the permissions of an existing
+// file are preserved, and new files are created with FileMode.
+func WithMode(perm fs.FileMode) AtomicOption {
+	return func(w *AtomicWriter) {
+		w.perm = perm
+		w.permSet = true
+	}
+}
+
+// AtomicWriter writes a file atomically: readers, and the file left after a
+// crash, either see the complete previous contents or the complete new
+// contents.
+//
+// Data is written to a temporary file in the same directory, which replaces
+// the destination when Commit is called. The temporary file and its directory
+// are synced to disk, so the new contents survive a power failure once Commit
+// returns. If Close is called without a successful Commit, the temporary file
+// is removed and the destination is left untouched:
+//
+//	w, err := fsutil.NewAtomicWriter(path)
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Close()
+//	if _, err := w.Write(data); err != nil {
+//		return err
+//	}
+//	return w.Commit()
+type AtomicWriter struct {
+	path    string
+	perm    fs.FileMode
+	permSet bool
+	f       *os.File
+	done    bool
+
+	// file operations, replaced in tests to simulate failures
+	sync   func(*os.File) error
+	rename func(oldpath, newpath string) error
+}
+
+// NewAtomicWriter creates an AtomicWriter for path, creating its temporary
+// file.
+func NewAtomicWriter(path string, opts ...AtomicOption) (*AtomicWriter, error) {
+	w := &AtomicWriter{
+		path:   path,
+		perm:   FileMode,
+		sync:   (*os.File).Sync,
+		rename: os.Rename,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if !w.permSet {
+		if info, err := os.Stat(path); err == nil {
+			w.perm = info.Mode().Perm()
+		}
+	}
+
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file for %s: %w", path, err)
+	}
+	w.f = f
+	return w, nil
+}
+
+// Write writes p to the temporary file.
+func (w *AtomicWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, os.ErrClosed
+	}
+	return w.f.Write(p)
+}
+
+// Commit syncs the temporary file to disk and renames it over the
+// destination. If Commit fails before the rename, the destination is left
+// untouched and the temporary file is removed. If only syncing the directory
+// fails after the rename, the destination already has the new contents, but
+// the rename may not survive a power failure.
+func (w *AtomicWriter) Commit() error {
+	if w.done {
+		return os.ErrClosed
+	}
+	w.done = true
+
+	tmp := w.f.Name()
+	if err := w.commit(tmp); err != nil {
+		w.f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (w *AtomicWriter) commit(tmp string) error {
+	if err := w.f.Chmod(w.perm); err != nil {
+		return fmt.Errorf("setting mode of %s: %w", tmp, err)
+	}
+	if err := w.sync(w.f); err != nil {
+		return fmt.Errorf("syncing %s: %w", tmp, err)
+	}
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", tmp, err)
+	}
+	if err := w.rename(tmp, w.path); err != nil {
+		return fmt.Errorf("renaming %s to %s: %w", tmp, w.path, err)
+	}
+	if err := syncDir(filepath.Dir(w.path)); err != nil {
+		return fmt.Errorf("syncing directory of %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// Close removes the temporary file if Commit has not been called. It is safe
+// to call Close after Commit.
+func (w *AtomicWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	return errors.Join(w.f.Close(), os.Remove(w.f.Name()))
+}
+
+// syncDir syncs a directory, so a rename in it survives a power failure.
+// Directories can't be opened for syncing on Windows, so it does nothing
+// there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// WriteFileAtomic writes data to the file at path like os.WriteFile, but
+// atomically, using an AtomicWriter. After a crash the file has either its
+// previous contents or data, never a partial write.
+func WriteFileAtomic(path string, data []byte, opts ...AtomicOption) error {
+	w, err := NewAtomicWriter(path, opts...)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return w.Commit()
+}
@@ -0,0 +1,140 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first")))
+	requireFile(t, path, "first")
+	requireNoTempFiles(t, dir)
+	if runtime.GOOS != "windows" {
+		requireMode(t, path, FileMode)
+
+		// the mode of an existing file is preserved unless it is set
+		require.NoError(t, os.Chmod(path, 0600))
+		require.NoError(t, WriteFileAtomic(path, []byte("second")))
+		requireMode(t, path, 0600)
+		require.NoError(t, WriteFileAtomic(path, []byte("third"), WithMode(0640)))
+		requireMode(t, path, 0640)
+	}
+
+	require.Error(t, WriteFileAtomic(filepath.Join(dir, "missing", "state.json"), []byte("x")))
+}
+
+func TestAtomicWriterFailures(t *testing.T) {
+	t.Parallel()
+
+	errInjected := errors.New("injected failure")
+
+	var tests = []struct {
+		name   string
+		opt    AtomicOption
+		commit bool
+	}{
+		{
+			name: "crash before commit",
+		},
+		{
+			name:   "sync fails",
+			opt:    func(w *AtomicWriter) { w.sync = func(*os.File) error { return errInjected } },
+			commit: true,
+		},
+		{
+			name:   "rename fails",
+			opt:    func(w *AtomicWriter) { w.rename = func(string, string) error { return errInjected } },
+			commit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "state.json")
+			require.NoError(t, os.WriteFile(path, []byte("original"), 0644))
+
+			var opts []AtomicOption
+			if tt.opt != nil {
+				opts = append(opts, tt.opt)
+			}
+			w, err := NewAtomicWriter(path, opts...)
+			require.NoError(t, err)
+			_, err = w.Write([]byte("partial"))
+			require.NoError(t, err)
+
+			// the destination is untouched while the write is in progress
+			requireFile(t, path, "original")
+
+			if tt.commit {
+				err := w.Commit()
+				require.Error(t, err)
+				require.True(t, errors.Is(err, errInjected))
+			}
+			require.NoError(t, w.Close())
+
+			requireFile(t, path, "original")
+			requireNoTempFiles(t, dir)
+
+			_, err = w.Write([]byte("more"))
+			require.Error(t, err, "writes after close must fail")
+		})
+	}
+}
+
+func TestAtomicWriterCommit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	w, err := NewAtomicWriter(path)
+	require.NoError(t, err)
+	defer w.Close()
+	_, err = w.Write([]byte("hello "))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("world"))
+	require.NoError(t, err)
+	require.NoError(t, w.Commit())
+	require.NoError(t, w.Close(), "close after commit is a no-op")
+	require.Error(t, w.Commit(), "commit can only be called once")
+
+	requireFile(t, path, "hello world")
+	requireNoTempFiles(t, dir)
+}
+
+func requireFile(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, want, string(got))
+}
+
+func requireMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, want, info.Mode().Perm())
+}
+
+func requireNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.NotContains(t, e.Name(), ".tmp", "temporary file left behind")
+	}
+}
@@ -0,0 +1,533 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// ExtractOption configures Extract.
+type ExtractOption func(*extractor)
+
+// WithMaxTotalSize limits the total number of bytes extracted. Zip archives
+// are also limited to this size, as they are buffered to a temporary file.
+// The default is 1 GiB.
+func WithMaxTotalSize(n int64) ExtractOption {
+	return func(e *extractor) {
+		e.maxTotalSize = n
+	}
+}
+
+// WithMaxFiles limits the number of entries extracted. The default is 10000.
+func WithMaxFiles(n int) ExtractOption {
+	return func(e *extractor) {
+		e.maxFiles = n
+	}
+}
+
+// WithMaxCompressionRatio limits the ratio of extracted bytes to archive
+// bytes, to protect against decompression bombs. The ratio is only enforced
+// once more than 1 MiB has been extracted. A ratio of 0 disables the limit.
+// The default is 100.
+func WithMaxCompressionRatio(ratio float64) ExtractOption {
+	return func(e *extractor) {
+		e.maxRatio = ratio
+	}
+}
+
+// WithStripComponents removes n leading path components from entry names,
+// like tar --strip-components. Entries with n or fewer components are skipped.
+func WithStripComponents(n int) ExtractOption {
+	return func(e *extractor) {
+		e.strip = n
+	}
+}
+
+// ratioMinSize is the size after which the compression ratio is enforced,
+// so that small, highly compressible archives are not rejected.
+const ratioMinSize = 1 << 20
+
+type extractor struct {
+	dest         string
+	maxTotalSize int64
+	maxFiles     int
+	maxRatio     float64
+	strip        int
+
+	archive *countingReader
+	written int64
+	files   int
+	links   []string // resolved paths of the symbolic links created
+}
+
+// Extract extracts the archive read from r into destDir, which is created if
+// it does not exist. The format is detected from the content: tar archives
+// compressed with gzip, zstd or xz, uncompressed tar archives, and zip
+// archives are supported.
+//
+// Extract is safe to use with untrusted archives:
+//
+//   - entries are never written outside destDir, and symbolic links must
+//     point inside it, even after later entries replace the links they
+//     resolve through; links which escape that way are removed
+//   - hard links must refer to files previously extracted from the archive
+//   - the total size, number of entries and compression ratio are limited
+//   - setuid, setgid and sticky bits are dropped, and device files and FIFOs
+//     are skipped
+//
+// An error is returned for the first entry which violates these rules.
+// Entries extracted before the error are left in place.
+func Extract(r io.Reader, destDir string, opts ...ExtractOption) error {
+	e := &extractor{
+		maxTotalSize: 1 << 30,
+		maxFiles:     10000,
+		maxRatio:     100,
+		archive:      &countingReader{r: r},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if err := os.MkdirAll(destDir, DirMode); err != nil {
+		return fmt.Errorf("creating destination: %w", err)
+	}
+	dest, err := filepath.EvalSymlinks(destDir)
+	if err != nil {
+		return fmt.Errorf("resolving destination: %w", err)
+	}
+	e.dest, err = filepath.Abs(dest)
+	if err != nil {
+		return fmt.Errorf("resolving destination: %w", err)
+	}
+
+	err = e.extract(e.archive)
+	// a later entry can replace a symbolic link which an earlier link
+	// resolves through, so every link is checked again at the end
+	if lerr := e.checkLinks(); err == nil {
+		err = lerr
+	}
+	return err
+}
+
+// extract detects the format of the archive read from r and extracts it.
+func (e *extractor) extract(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(6)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("creating gzip reader: %w", err)
+		}
+		defer zr.Close()
+		return e.extractTar(zr)
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("creating zstd reader: %w", err)
+		}
+		defer zr.Close()
+		return e.extractTar(zr)
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		zr, err := xz.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("creating xz reader: %w", err)
+		}
+		return e.extractTar(zr)
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return e.extractZip(br)
+	default:
+		return e.extractTar(br)
+	}
+}
+
+func (e *extractor) extractTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading tar archive: %w", err)
+		}
+
+		if err := e.tarEntry(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *extractor) tarEntry(header *tar.Header, r io.Reader) error {
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return e.dir(header.Name, header.FileInfo().Mode())
+	case tar.TypeReg:
+		return e.file(header.Name, header.FileInfo().Mode(), r)
+	case tar.TypeSymlink:
+		return e.symlink(header.Name, header.Linkname)
+	case tar.TypeLink:
+		return e.hardlink(header.Name, header.Linkname)
+	default:
+		// devices, FIFOs and metadata entries are not extracted
+		return nil
+	}
+}
+
+func (e *extractor) extractZip(r io.Reader) error {
+	// zip archives are read from the end, so they must be buffered
+	tmp, err := os.CreateTemp("", "fsutil-extract-*.zip")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, e.maxTotalSize+1))
+	if err != nil {
+		return fmt.Errorf("buffering zip archive: %w", err)
+	}
+	if size > e.maxTotalSize {
+		return fmt.Errorf("zip archive exceeds the size limit of %d bytes", e.maxTotalSize)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return fmt.Errorf("reading zip archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if err := e.zipEntry(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *extractor) zipEntry(f *zip.File) error {
+	mode := f.Mode()
+	switch {
+	case mode.IsDir():
+		return e.dir(f.Name, mode)
+	case mode&fs.ModeSymlink != 0:
+		target, err := readZipFile(f, 4096)
+		if err != nil {
+			return err
+		}
+		return e.symlink(f.Name, string(target))
+	case mode.IsRegular():
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("opening %s: %w", f.Name, err)
+		}
+		defer rc.Close()
+		return e.file(f.Name, mode, rc)
+	default:
+		return nil
+	}
+}
+
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+	}
+	return data, nil
+}
+
+// target returns the path within the destination for an entry name, after
+// stripping leading components. It returns "" for entries which are skipped.
+func (e *extractor) target(name string) (string, error) {
+	var parts []string
+	for _, p := range strings.Split(strings.ReplaceAll(name, `\`, "/"), "/") {
+		switch p {
+		case "", ".":
+		case "..":
+			return "", fmt.Errorf("%s: illegal file path", name)
+		default:
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) <= e.strip {
+		return "", nil
+	}
+	target := filepath.Join(e.dest, filepath.Join(parts[e.strip:]...))
+	if !within(e.dest, target) {
+		return "", fmt.Errorf("%s: illegal file path", name)
+	}
+	return target, nil
+}
+
+// count enforces the entry limit.
+func (e *extractor) count() error {
+	e.files++
+	if e.files > e.maxFiles {
+		return fmt.Errorf("archive exceeds the limit of %d entries", e.maxFiles)
+	}
+	return nil
+}
+
+// prepare creates the parent directories of target, checks that they resolve
+// within the destination, and removes any existing file at target so it is
+// replaced instead of followed.
+func (e *extractor) prepare(target string) error {
+	parent := filepath.Dir(target)
+	if err := os.MkdirAll(parent, DirMode); err != nil {
+		return fmt.Errorf("creating directory %s: %w", parent, err)
+	}
+	real, err := filepath.EvalSymlinks(parent)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", parent, err)
+	}
+	if !within(e.dest, real) {
+		return fmt.Errorf("%s: illegal file path", target)
+	}
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return fmt.Errorf("replacing %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+func (e *extractor) dir(name string, mode fs.FileMode) error {
+	target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	if err := e.count(); err != nil {
+		return err
+	}
+	if err := e.prepare(target); err != nil {
+		return err
+	}
+	// directories must stay writable by the owner to extract their contents
+	if err := os.MkdirAll(target, mode.Perm()|0700); err != nil {
+		return fmt.Errorf("creating directory %s: %w", target, err)
+	}
+	return nil
+}
+
+func (e *extractor) file(name string, mode fs.FileMode, r io.Reader) error {
+	target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	if err := e.count(); err != nil {
+		return err
+	}
+	if err := e.prepare(target); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", target, err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, &limitedReader{e: e, r: r})
+	e.written += n
+	if err != nil {
+		return fmt.Errorf("extracting %s: %w", name, err)
+	}
+	return f.Close()
+}
+
+func (e *extractor) symlink(name, linkname string) error {
+	target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	if err := e.count(); err != nil {
+		return err
+	}
+	if err := e.prepare(target); err != nil {
+		return err
+	}
+
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", target, err)
+	}
+	linkname = filepath.FromSlash(linkname)
+	if _, _, ok := e.resolve(parent, linkname, 0); !ok {
+		return fmt.Errorf("%s: symbolic link to %s escapes the destination", name, linkname)
+	}
+	if err := os.Symlink(linkname, target); err != nil {
+		return fmt.Errorf("creating symbolic link %s: %w", target, err)
+	}
+	e.links = append(e.links, filepath.Join(parent, filepath.Base(target)))
+	return nil
+}
+
+// checkLinks checks that the symbolic links created still point inside the
+// destination, and removes those which don't. Links which have since been
+// replaced by other entries are skipped.
+func (e *extractor) checkLinks() error {
+	var escaped []string
+	for _, link := range e.links {
+		linkname, err := os.Readlink(link)
+		if err != nil {
+			continue
+		}
+		if _, _, ok := e.resolve(filepath.Dir(link), linkname, 0); ok {
+			continue
+		}
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing symbolic link %s: %w", link, err)
+		}
+		if rel, err := filepath.Rel(e.dest, link); err == nil {
+			link = filepath.ToSlash(rel)
+		}
+		escaped = append(escaped, link)
+	}
+	if len(escaped) > 0 {
+		return fmt.Errorf("symbolic links escape the destination after later entries: %s", strings.Join(escaped, ", "))
+	}
+	return nil
+}
+
+func (e *extractor) hardlink(name, linkname string) error {
+	target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	source, err := e.target(linkname)
+	if err != nil {
+		return err
+	}
+	if source == "" {
+		return fmt.Errorf("%s: hard link to %s, which was not extracted", name, linkname)
+	}
+	if err := e.count(); err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(source)
+	if err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: hard link to %s, which is not an extracted file", name, linkname)
+	}
+	real, err := filepath.EvalSymlinks(source)
+	if err != nil || !within(e.dest, real) {
+		return fmt.Errorf("%s: hard link to %s escapes the destination", name, linkname)
+	}
+	if err := e.prepare(target); err != nil {
+		return err
+	}
+	if err := os.Link(real, target); err != nil {
+		return fmt.Errorf("creating hard link %s: %w", target, err)
+	}
+	return nil
+}
+
+// maxLinkHops limits how many symbolic links resolve follows, which also
+// stops it on loops.
+const maxLinkHops = 255
+
+// resolve returns the path linkname points to, relative to the directory dir,
+// following the symbolic links already in the destination. ok is false if
+// linkname is absolute or leaves the destination at any step.
+//
+// Components from the first one that doesn't exist yet are resolved
+// lexically, and complete is false. They must not contain "..", since an
+// entry extracted later could turn a missing component into a symbolic link
+// and change where ".." leads.
+func (e *extractor) resolve(dir, linkname string, hops int) (path string, complete, ok bool) {
+	if filepath.IsAbs(linkname) || hops > maxLinkHops {
+		return "", false, false
+	}
+
+	path, complete = dir, true
+	for _, part := range strings.Split(linkname, string(filepath.Separator)) {
+		switch {
+		case part == "" || part == ".":
+			continue
+		case part == "..":
+			if !complete {
+				return "", false, false
+			}
+			path = filepath.Dir(path)
+		case !complete:
+			path = filepath.Join(path, part)
+		default:
+			next := filepath.Join(path, part)
+			info, err := os.Lstat(next)
+			switch {
+			case os.IsNotExist(err):
+				path, complete = next, false
+			case err != nil:
+				return "", false, false
+			case info.Mode()&fs.ModeSymlink != 0:
+				target, err := os.Readlink(next)
+				if err != nil {
+					return "", false, false
+				}
+				if path, complete, ok = e.resolve(path, target, hops+1); !ok {
+					return "", false, false
+				}
+			default:
+				path = next
+			}
+		}
+		if !within(e.dest, path) {
+			return "", false, false
+		}
+	}
+	return path, complete, true
+}
+
+// within reports whether path is dir or inside it. Both must be clean and
+// absolute.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// limitedReader enforces the size and compression ratio limits while an
+// entry is extracted.
+type limitedReader struct {
+	e    *extractor
+	r    io.Reader
+	read int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	total := l.e.written + l.read
+	if total > l.e.maxTotalSize {
+		return n, fmt.Errorf("archive exceeds the size limit of %d bytes", l.e.maxTotalSize)
+	}
+	if l.e.maxRatio > 0 && total > ratioMinSize && float64(total) > l.e.maxRatio*float64(l.e.archive.n) {
+		return n, fmt.Errorf("archive exceeds the compression ratio limit of %g", l.e.maxRatio)
+	}
+	return n, err
+}
+
+// countingReader counts the bytes read from the archive.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,339 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+type testEntry struct {
+	name     string
+	body     string
+	mode     fs.FileMode
+	linkname string
+	hardlink bool
+}
+
+var testEntries = []testEntry{
+	{name: "bundle/", mode: fs.ModeDir | 0755},
+	{name: "bundle/bin/", mode: fs.ModeDir | 0755},
+	{name: "bundle/bin/tool", body: "#!/bin/sh\n", mode: 0755},
+	{name: "bundle/README", body: "hello", mode: 0644},
+	{name: "bundle/docs", linkname: "README", mode: fs.ModeSymlink | 0777},
+}
+
+func buildTar(t *testing.T, compression string, entries []testEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	case "xz":
+		zw, err := xz.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	default:
+		w = nopWriteCloser{&buf}
+	}
+
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm()), Size: int64(len(e.body))}
+		switch {
+		case e.hardlink:
+			h.Typeflag, h.Linkname, h.Size = tar.TypeLink, e.linkname, 0
+		case e.mode&fs.ModeSymlink != 0:
+			h.Typeflag, h.Linkname, h.Size = tar.TypeSymlink, e.linkname, 0
+		case e.mode.IsDir():
+			h.Typeflag = tar.TypeDir
+		default:
+			h.Typeflag = tar.TypeReg
+		}
+		require.NoError(t, tw.WriteHeader(h))
+		if h.Size > 0 {
+			_, err := tw.Write([]byte(e.body))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		h.SetMode(e.mode)
+		w, err := zw.CreateHeader(h)
+		require.NoError(t, err)
+		body := e.body
+		if e.mode&fs.ModeSymlink != 0 {
+			body = e.linkname
+		}
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestExtractFormats(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires extra privileges on windows")
+	}
+
+	var tests = []struct {
+		name    string
+		archive func(t *testing.T) []byte
+	}{
+		{"tar", func(t *testing.T) []byte { return buildTar(t, "", testEntries) }},
+		{"gzip", func(t *testing.T) []byte { return buildTar(t, "gzip", testEntries) }},
+		{"zstd", func(t *testing.T) []byte { return buildTar(t, "zstd", testEntries) }},
+		{"xz", func(t *testing.T) []byte { return buildTar(t, "xz", testEntries) }},
+		{"zip", func(t *testing.T) []byte { return buildZip(t, testEntries) }},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dest := t.TempDir()
+			require.NoError(t, Extract(bytes.NewReader(tt.archive(t)), dest, WithStripComponents(1)))
+
+			requireFile(t, filepath.Join(dest, "README"), "hello")
+			requireFile(t, filepath.Join(dest, "bin", "tool"), "#!/bin/sh\n")
+			requireMode(t, filepath.Join(dest, "bin", "tool"), 0755)
+			link, err := os.Readlink(filepath.Join(dest, "docs"))
+			require.NoError(t, err)
+			require.Equal(t, "README", link)
+			_, err = os.Lstat(filepath.Join(dest, "bundle"))
+			require.True(t, os.IsNotExist(err), "leading components are stripped")
+		})
+	}
+}
+
+func TestExtractHardlink(t *testing.T) {
+	t.Parallel()
+
+	dest := t.TempDir()
+	archive := buildTar(t, "gzip", []testEntry{
+		{name: "a.txt", body: "shared", mode: 0644},
+		{name: "b.txt", linkname: "a.txt", hardlink: true},
+	})
+	require.NoError(t, Extract(bytes.NewReader(archive), dest))
+	requireFile(t, filepath.Join(dest, "b.txt"), "shared")
+
+	a, err := os.Stat(filepath.Join(dest, "a.txt"))
+	require.NoError(t, err)
+	b, err := os.Stat(filepath.Join(dest, "b.txt"))
+	require.NoError(t, err)
+	require.True(t, os.SameFile(a, b))
+}
+
+func TestExtractReplacedSymlink(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires extra privileges on windows")
+	}
+
+	// later entries may replace links as long as everything stays inside
+	dest := t.TempDir()
+	archive := buildTar(t, "", []testEntry{
+		{name: "a/", mode: fs.ModeDir | 0755},
+		{name: "b/", mode: fs.ModeDir | 0755},
+		{name: "b/file", body: "b", mode: 0644},
+		{name: "current", linkname: "a", mode: fs.ModeSymlink},
+		{name: "file", linkname: "current/../b/file", mode: fs.ModeSymlink},
+		{name: "current", linkname: "b", mode: fs.ModeSymlink},
+	})
+	require.NoError(t, Extract(bytes.NewReader(archive), dest))
+	requireFile(t, filepath.Join(dest, "current", "file"), "b")
+}
+
+func TestExtractRejectsEscapes(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires extra privileges on windows")
+	}
+
+	var tests = []struct {
+		name    string
+		entries []testEntry
+	}{
+		{
+			name:    "parent path",
+			entries: []testEntry{{name: "../evil", body: "x", mode: 0644}},
+		},
+		{
+			name:    "absolute symlink",
+			entries: []testEntry{{name: "etc", linkname: "/etc", mode: fs.ModeSymlink}},
+		},
+		{
+			name:    "relative symlink",
+			entries: []testEntry{{name: "up", linkname: "../outside", mode: fs.ModeSymlink}},
+		},
+		{
+			name: "symlink through another symlink",
+			entries: []testEntry{
+				{name: "self", linkname: ".", mode: fs.ModeSymlink},
+				{name: "self/sub/up", linkname: "../..", mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name: "symlink chain",
+			entries: []testEntry{
+				{name: "y", linkname: ".", mode: fs.ModeSymlink},
+				{name: "x", linkname: "y/..", mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name: "symlink chain created out of order",
+			entries: []testEntry{
+				{name: "x", linkname: "y/..", mode: fs.ModeSymlink},
+				{name: "y", linkname: ".", mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name: "symlink through a dangling symlink",
+			entries: []testEntry{
+				{name: "d", linkname: "missing", mode: fs.ModeSymlink},
+				{name: "x", linkname: "d/..", mode: fs.ModeSymlink},
+				{name: "missing", linkname: ".", mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name: "symlink loop",
+			entries: []testEntry{
+				{name: "loop", linkname: "loop/x", mode: fs.ModeSymlink},
+				{name: "x", linkname: "loop/..", mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name: "symlink retargeted by a later entry",
+			entries: []testEntry{
+				{name: "a/b/", mode: fs.ModeDir | 0755},
+				{name: "s", linkname: "a/b", mode: fs.ModeSymlink},
+				{name: "l", linkname: "s/../..", mode: fs.ModeSymlink},
+				{name: "s", linkname: ".", mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name:    "hardlink outside",
+			entries: []testEntry{{name: "passwd", linkname: "/etc/passwd", hardlink: true}},
+		},
+		{
+			name:    "hardlink to parent path",
+			entries: []testEntry{{name: "passwd", linkname: "../passwd", hardlink: true}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+			err := Extract(bytes.NewReader(buildTar(t, "", tt.entries)), dest)
+			require.Error(t, err)
+
+			entries, err := os.ReadDir(root)
+			require.NoError(t, err)
+			require.Len(t, entries, 1, "nothing may be written outside the destination")
+
+			// no link left behind may point outside the destination
+			realDest, err := filepath.EvalSymlinks(dest)
+			require.NoError(t, err)
+			err = filepath.WalkDir(dest, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.Type()&fs.ModeSymlink == 0 {
+					return err
+				}
+				if real, err := filepath.EvalSymlinks(path); err == nil {
+					require.True(t, within(realDest, real), "%s resolves to %s", path, real)
+				}
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	t.Parallel()
+
+	zeros := strings.Repeat("\x00", 4<<20)
+	var tests = []struct {
+		name    string
+		archive []byte
+		opts    []ExtractOption
+		wantErr string
+	}{
+		{
+			name:    "total size",
+			archive: buildTar(t, "", []testEntry{{name: "big", body: strings.Repeat("x", 1024), mode: 0644}}),
+			opts:    []ExtractOption{WithMaxTotalSize(512)},
+			wantErr: "size limit",
+		},
+		{
+			name: "file count",
+			archive: buildTar(t, "", []testEntry{
+				{name: "a", body: "a", mode: 0644},
+				{name: "b", body: "b", mode: 0644},
+			}),
+			opts:    []ExtractOption{WithMaxFiles(1)},
+			wantErr: "limit of 1 entries",
+		},
+		{
+			name:    "compression ratio",
+			archive: buildTar(t, "gzip", []testEntry{{name: "bomb", body: zeros, mode: 0644}}),
+			wantErr: "compression ratio",
+		},
+		{
+			name:    "zip compression ratio",
+			archive: buildZip(t, []testEntry{{name: "bomb", body: zeros, mode: 0644}}),
+			wantErr: "compression ratio",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := Extract(bytes.NewReader(tt.archive), t.TempDir(), tt.opts...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	// the ratio limit can be disabled
+	archive := buildTar(t, "gzip", []testEntry{{name: "zeros", body: zeros, mode: 0644}})
+	require.NoError(t, Extract(bytes.NewReader(archive), t.TempDir(), WithMaxCompressionRatio(0)))
+}
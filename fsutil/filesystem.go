@@ -90,7 +90,8 @@ func CopyFile(src, dest string) error {
 // UntarBundle will untar a source tar.gz archive to the supplied
 // destination. Note that this calls `filepath.Dir(destination)`,
 // which has the effect of stripping the last component from
-// destination.
+// destination. Extract supports more formats and is safe to use with
+// untrusted archives.
 func UntarBundle(destination string, source string) error {
 	f, err := os.Open(source)
 	if err != nil {
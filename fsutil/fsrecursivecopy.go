@@ -55,7 +55,7 @@ func genCopyToDiskFunc(srcFS fs.FS, destDir string, modeSetter func(fs.FileInfo)
 			return fmt.Errorf("reading file from FS %s: %w", filepath, err)
 		}
 
-		if err := os.WriteFile(fullpath, data, modeSetter(fileinfo)); err != nil {
+		if err := WriteFileAtomic(fullpath, data, WithMode(modeSetter(fileinfo))); err != nil {
 			return fmt.Errorf("writing %s: %w", filepath, err)
 		}
 
@@ -7,11 +7,14 @@ require (
 	github.com/go-kit/kit v0.7.0
 	github.com/google/uuid v1.6.0
 	github.com/jmoiron/sqlx v0.0.0-20180406164412-2aeb6a910c2b
+	github.com/klauspost/compress v1.18.0
 	github.com/oklog/ulid v0.3.0
 	github.com/opencensus-integrations/ocsql v0.1.1
 	github.com/pkg/errors v0.8.0
 	github.com/stretchr/testify v1.2.1
+	github.com/ulikunitz/xz v0.5.15
 	go.opencensus.io v0.22.1
+	golang.org/x/sys v0.45.0
 	google.golang.org/grpc v1.79.3
 )
 
@@ -26,7 +29,6 @@ require (
 	github.com/mattn/go-sqlite3 v1.10.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/net v0.55.0 // indirect
-	golang.org/x/sys v0.45.0 // indirect
 	golang.org/x/text v0.37.0 // indirect
 	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
 	google.golang.org/protobuf v1.36.10 // indirect
@@ -0,0 +1,199 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the outcome of a health check.
+type Status string
+
+// Possible values for Status. Checks pass, warn or fail, while a Report is
+// degraded when a check warns or a non-critical check fails.
+const (
+	StatusPass     Status = "pass"
+	StatusWarn     Status = "warn"
+	StatusFail     Status = "fail"
+	StatusDegraded Status = "degraded"
+)
+
+// Option configures how health checks are run.
+type Option func(*options)
+
+// WithCheckTimeout sets the time each checker has to complete before it is
+// reported as failed. The default is 5 seconds.
+func WithCheckTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.checkTimeout = d
+	}
+}
+
+// WithTimeout sets the time all checkers have to complete. Checkers which
+// are still running when it expires are reported as failed.
+// The default is 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+type options struct {
+	checkTimeout time.Duration
+	timeout      time.Duration
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		checkTimeout: 5 * time.Second,
+		timeout:      10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CheckResult is the outcome of a single checker.
+type CheckResult struct {
+	Result
+	Critical bool
+	Duration time.Duration
+}
+
+// MarshalJSON encodes the error as its message and the duration as a string,
+// like "12.5ms".
+func (r CheckResult) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Status   Status                 `json:"status"`
+		Message  string                 `json:"message,omitempty"`
+		Error    string                 `json:"error,omitempty"`
+		Metadata map[string]interface{} `json:"metadata,omitempty"`
+		Critical bool                   `json:"critical"`
+		Duration string                 `json:"duration"`
+	}{
+		Status:   r.Status,
+		Message:  r.Message,
+		Metadata: r.Metadata,
+		Critical: r.Critical,
+		Duration: r.Duration.String(),
+	}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	return json.Marshal(out)
+}
+
+// Report is the outcome of running a set of checkers.
+type Report struct {
+	Status   Status                 `json:"status"`
+	Checks   map[string]CheckResult `json:"checks,omitempty"`
+	Duration time.Duration          `json:"-"`
+}
+
+// Healthy reports whether every critical checker passed or warned, in which
+// case probes should pass. The report may still be degraded.
+func (r Report) Healthy() bool {
+	return r.Status != StatusFail
+}
+
+// Failed returns the names of the failed checkers, sorted.
+func (r Report) Failed() []string {
+	var names []string
+	for name, res := range r.Checks {
+		if res.Status == StatusFail {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// errTimeout is reported for checkers which did not complete in time.
+var errTimeout = errors.New("health check timed out")
+
+// Check runs all checkers concurrently and returns a report of their results.
+// A checker which does not return before its timeout, the overall timeout or
+// the cancellation of ctx is reported as failed. Since Checker does not accept
+// a context, such a checker keeps running in the background until it returns.
+func Check(ctx context.Context, checkers map[string]Checker, opts ...Option) Report {
+	o := newOptions(opts)
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(checkers))
+	)
+	for name, hc := range checkers {
+		wg.Add(1)
+		go func(name string, hc Checker) {
+			defer wg.Done()
+			res := runCheck(ctx, hc, o.checkTimeout)
+			mu.Lock()
+			results[name] = res
+			mu.Unlock()
+		}(name, hc)
+	}
+	wg.Wait()
+
+	return Report{
+		Status:   aggregate(results),
+		Checks:   results,
+		Duration: time.Since(start),
+	}
+}
+
+// aggregate returns the status of a report with results.
+func aggregate(results map[string]CheckResult) Status {
+	status := StatusPass
+	for _, res := range results {
+		switch {
+		case res.Status == StatusFail && res.Critical:
+			return StatusFail
+		case res.Status != StatusPass:
+			status = StatusDegraded
+		}
+	}
+	return status
+}
+
+// runCheck runs a single checker, giving up when timeout elapses or ctx is
+// done. The context passed to a ContextChecker is cancelled at the same time.
+func runCheck(ctx context.Context, hc Checker, timeout time.Duration) CheckResult {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- Fail(fmt.Errorf("health check panicked: %v", r))
+			}
+		}()
+		done <- Adapt(hc).Check(ctx)
+	}()
+
+	var res Result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res = Fail(errTimeout)
+	}
+	if res.Status == StatusFail && res.Error == nil {
+		res.Error = resultError(res)
+	}
+	return CheckResult{
+		Result:   res,
+		Critical: isCritical(hc),
+		Duration: time.Since(start),
+	}
+}
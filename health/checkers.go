@@ -0,0 +1,338 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func() error
+
+// HealthCheck calls fn.
+func (fn CheckerFunc) HealthCheck() error {
+	return fn()
+}
+
+// SQLOption configures a checker created by SQLChecker.
+type SQLOption func(*sqlChecker)
+
+// WithPingTimeout sets the time the database has to respond to a ping.
+// The default is 2 seconds.
+func WithPingTimeout(d time.Duration) SQLOption {
+	return func(c *sqlChecker) {
+		c.timeout = d
+	}
+}
+
+// WithMaxInUse fails the check when more than ratio of the maximum open
+// connections are in use, for example 0.9. It has no effect on pools without
+// a maximum. By default pool usage is not checked.
+func WithMaxInUse(ratio float64) SQLOption {
+	return func(c *sqlChecker) {
+		c.maxInUse = ratio
+	}
+}
+
+// WithMaxWaits fails the check when more than n queries had to wait for a
+// connection since the previous check. By default waits are not checked.
+func WithMaxWaits(n int64) SQLOption {
+	return func(c *sqlChecker) {
+		c.maxWaits = n
+	}
+}
+
+type sqlChecker struct {
+	db       *sql.DB
+	timeout  time.Duration
+	maxInUse float64
+	maxWaits int64
+
+	mu        sync.Mutex
+	waitCount int64
+}
+
+// SQLChecker returns a Checker which pings db and checks that its connection
+// pool is not saturated.
+func SQLChecker(db *sql.DB, opts ...SQLOption) Checker {
+	c := &sqlChecker{
+		db:       db,
+		timeout:  2 * time.Second,
+		maxWaits: -1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.waitCount = db.Stats().WaitCount
+	return c
+}
+
+func (c *sqlChecker) HealthCheck() error {
+	return resultError(c.Check(context.Background()))
+}
+
+// Check pings the database and reports the pool statistics as metadata.
+func (c *sqlChecker) Check(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if err := c.db.PingContext(ctx); err != nil {
+		return Fail(fmt.Errorf("pinging database: %w", err))
+	}
+
+	stats := c.db.Stats()
+	c.mu.Lock()
+	waits := stats.WaitCount - c.waitCount
+	c.waitCount = stats.WaitCount
+	c.mu.Unlock()
+
+	res := Pass()
+	res.Metadata = map[string]interface{}{
+		"open":       stats.OpenConnections,
+		"in_use":     stats.InUse,
+		"max_open":   stats.MaxOpenConnections,
+		"wait_count": waits,
+	}
+	if c.maxInUse > 0 && stats.MaxOpenConnections > 0 {
+		if ratio := float64(stats.InUse) / float64(stats.MaxOpenConnections); ratio > c.maxInUse {
+			res.Status = StatusFail
+			res.Error = fmt.Errorf("database pool saturated: %d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+			return res
+		}
+	}
+	if c.maxWaits >= 0 && waits > c.maxWaits {
+		res.Status = StatusFail
+		res.Error = fmt.Errorf("database pool saturated: %d queries waited for a connection since the last check", waits)
+	}
+	return res
+}
+
+// TCPChecker returns a Checker which dials addr, failing if a TCP connection
+// cannot be established within timeout.
+func TCPChecker(addr string, timeout time.Duration) Checker {
+	return &tcpChecker{addr: addr, timeout: timeout}
+}
+
+type tcpChecker struct {
+	addr    string
+	timeout time.Duration
+}
+
+func (c *tcpChecker) HealthCheck() error {
+	return resultError(c.Check(context.Background()))
+}
+
+// Check dials addr, giving up when ctx is done or the timeout expires.
+func (c *tcpChecker) Check(ctx context.Context) Result {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return Fail(fmt.Errorf("dialing %s: %w", c.addr, err))
+	}
+	if err := conn.Close(); err != nil {
+		return Fail(err)
+	}
+	return Pass()
+}
+
+// HTTPOption configures a checker created by HTTPChecker.
+type HTTPOption func(*httpChecker)
+
+// WithHTTPClient sets the client used for requests. The default client has a
+// timeout of 5 seconds.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(c *httpChecker) {
+		c.client = client
+	}
+}
+
+// WithExpectedStatus sets the response status codes which pass the check.
+// The default is 200 OK.
+func WithExpectedStatus(codes ...int) HTTPOption {
+	return func(c *httpChecker) {
+		c.statuses = codes
+	}
+}
+
+type httpChecker struct {
+	url      string
+	client   *http.Client
+	statuses []int
+}
+
+// HTTPChecker returns a Checker which makes a GET request to url and checks
+// the response status code.
+func HTTPChecker(url string, opts ...HTTPOption) Checker {
+	c := &httpChecker{
+		url:      url,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		statuses: []int{http.StatusOK},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *httpChecker) HealthCheck() error {
+	return resultError(c.Check(context.Background()))
+}
+
+// Check makes the request, which is cancelled when ctx is done.
+func (c *httpChecker) Check(ctx context.Context) Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return Fail(fmt.Errorf("creating request: %w", err))
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Fail(fmt.Errorf("requesting %s: %w", c.url, err))
+	}
+	resp.Body.Close()
+	for _, code := range c.statuses {
+		if resp.StatusCode == code {
+			return Pass()
+		}
+	}
+	return Fail(fmt.Errorf("requesting %s: unexpected status %s", c.url, resp.Status))
+}
+
+// DiskSpaceChecker returns a Checker which fails when the file system
+// containing path has less than minFree bytes available.
+func DiskSpaceChecker(path string, minFree uint64) Checker {
+	return CheckerFunc(func() error {
+		free, err := freeDiskSpace(path)
+		if err != nil {
+			return fmt.Errorf("checking free space on %s: %w", path, err)
+		}
+		if free < minFree {
+			return fmt.Errorf("%s free on %s, need %s", formatBytes(free), path, formatBytes(minFree))
+		}
+		return nil
+	})
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// GoroutineChecker returns a Checker which fails when more than max
+// goroutines are running, which usually indicates a leak.
+func GoroutineChecker(max int) Checker {
+	return CheckerFunc(func() error {
+		if n := runtime.NumGoroutine(); n > max {
+			return fmt.Errorf("%d goroutines running, the limit is %d", n, max)
+		}
+		return nil
+	})
+}
+
+// TLSChecker returns a Checker which connects to addr and fails when the
+// server's certificate chain expires within minValidity. cfg may be nil.
+// Certificates are not verified, so expired certificates are reported as
+// such rather than as handshake errors.
+func TLSChecker(addr string, minValidity time.Duration, cfg *tls.Config) Checker {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+	cfg.InsecureSkipVerify = true
+	if cfg.ServerName == "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		}
+	}
+	return &tlsChecker{addr: addr, minValidity: minValidity, cfg: cfg}
+}
+
+type tlsChecker struct {
+	addr        string
+	minValidity time.Duration
+	cfg         *tls.Config
+}
+
+func (c *tlsChecker) HealthCheck() error {
+	return resultError(c.Check(context.Background()))
+}
+
+// Check connects to addr, giving up when ctx is done or after 5 seconds.
+func (c *tlsChecker) Check(ctx context.Context) Result {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 5 * time.Second},
+		Config:    c.cfg,
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return Fail(fmt.Errorf("connecting to %s: %w", c.addr, err))
+	}
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+	if err := checkExpiry(state.PeerCertificates, c.minValidity, time.Now()); err != nil {
+		return Fail(err)
+	}
+	return Pass()
+}
+
+// CertFileChecker returns a Checker which fails when a certificate in the PEM
+// file at path expires within minValidity. The file is read on every check,
+// so renewed certificates are picked up.
+func CertFileChecker(path string, minValidity time.Duration) Checker {
+	return CheckerFunc(func() error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading certificates: %w", err)
+		}
+		var certs []*x509.Certificate
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("parsing certificate in %s: %w", path, err)
+			}
+			certs = append(certs, cert)
+		}
+		if len(certs) == 0 {
+			return fmt.Errorf("no certificates found in %s", path)
+		}
+		return checkExpiry(certs, minValidity, time.Now())
+	})
+}
+
+func checkExpiry(certs []*x509.Certificate, minValidity time.Duration, now time.Time) error {
+	var expiring []string
+	for _, cert := range certs {
+		if cert.NotAfter.Sub(now) < minValidity {
+			expiring = append(expiring, fmt.Sprintf("%q expires %s", cert.Subject.CommonName, cert.NotAfter.UTC().Format(time.RFC3339)))
+		}
+	}
+	if len(expiring) > 0 {
+		return fmt.Errorf("certificate expiring within %s: %s", minValidity, strings.Join(expiring, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLChecker(t *testing.T) {
+	t.Parallel()
+
+	db := sql.OpenDB(fakeConnector{})
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	hc := SQLChecker(db, WithMaxInUse(0.5), WithMaxWaits(0))
+	require.NoError(t, hc.HealthCheck())
+	res := hc.(ContextChecker).Check(t.Context())
+	require.Equal(t, StatusPass, res.Status)
+	require.Equal(t, 1, res.Metadata["max_open"])
+
+	// hold the only connection and make another query wait for it
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	waited := make(chan struct{})
+	go func() {
+		defer close(waited)
+		c, err := db.Conn(ctx)
+		if err == nil {
+			c.Close()
+		}
+	}()
+	for db.Stats().WaitCount == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	err = SQLChecker(db, WithMaxInUse(0.5), WithPingTimeout(10*time.Millisecond)).HealthCheck()
+	require.Error(t, err)
+
+	conn.Close()
+	<-waited
+	err = hc.HealthCheck()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "queries waited")
+	require.NoError(t, hc.HealthCheck(), "waits are counted since the previous check")
+
+	failing := SQLChecker(sql.OpenDB(fakeConnector{err: errors.New("connection refused")}))
+	require.Error(t, failing.HealthCheck())
+}
+
+func TestTCPChecker(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, TCPChecker(addr, time.Second).HealthCheck())
+
+	// the dial is abandoned with its context
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	res := TCPChecker(addr, time.Second).(ContextChecker).Check(ctx)
+	require.Equal(t, StatusFail, res.Status)
+	require.True(t, errors.Is(res.Error, context.Canceled), res.Error)
+
+	ln.Close()
+	require.Error(t, TCPChecker(addr, time.Second).HealthCheck())
+}
+
+func TestHTTPChecker(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	require.NoError(t, HTTPChecker(srv.URL).HealthCheck())
+	err := HTTPChecker(srv.URL + "/missing").HealthCheck()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404 Not Found")
+	require.NoError(t, HTTPChecker(srv.URL+"/missing", WithExpectedStatus(http.StatusNotFound)).HealthCheck())
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, DiskSpaceChecker(dir, 1).HealthCheck())
+	require.Error(t, DiskSpaceChecker(dir, math.MaxUint64).HealthCheck())
+	require.Error(t, DiskSpaceChecker(filepath.Join(dir, "missing"), 1).HealthCheck())
+}
+
+func TestGoroutineChecker(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, GoroutineChecker(1<<20).HealthCheck())
+	require.Error(t, GoroutineChecker(1).HealthCheck())
+}
+
+func TestCertificateCheckers(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// the checkers close connections right after the handshake
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	addr := srv.Listener.Addr().String()
+
+	require.NoError(t, TLSChecker(addr, time.Hour, nil).HealthCheck())
+	err := TLSChecker(addr, 200*365*24*time.Hour, nil).HealthCheck()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "expiring")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	res := TLSChecker(addr, time.Hour, nil).(ContextChecker).Check(ctx)
+	require.Equal(t, StatusFail, res.Status)
+	require.True(t, errors.Is(res.Error, context.Canceled), res.Error)
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	require.NoError(t, CertFileChecker(path, time.Hour).HealthCheck())
+	require.Error(t, CertFileChecker(path, 200*365*24*time.Hour).HealthCheck())
+	require.Error(t, CertFileChecker(filepath.Join(t.TempDir(), "missing.pem"), time.Hour).HealthCheck())
+}
+
+// fakeConnector is a database/sql connector whose connections only support
+// pings.
+type fakeConnector struct {
+	err error
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return fakeConn{}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not implemented") }
+func (fakeConn) Ping(context.Context) error          { return nil }
@@ -0,0 +1,290 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCOption configures a GRPCServer.
+type GRPCOption func(*GRPCServer)
+
+// WithService maps a gRPC service name to the named checkers from the map
+// passed to NewGRPCServer. Probes for the service only run those checkers.
+// NewGRPCServer panics if no checkers are named, or if a name is not in the
+// map.
+func WithService(service string, checkerNames ...string) GRPCOption {
+	return func(s *GRPCServer) {
+		s.groups[service] = checkerNames
+	}
+}
+
+// WithServiceFunc maps a gRPC service name to fn, so the status of the
+// service can come from Probes.Check or Runner.Report:
+//
+//	health.WithServiceFunc("liveness", func(ctx context.Context) health.Report {
+//		return probes.Check(ctx, health.Liveness)
+//	})
+func WithServiceFunc(service string, fn func(context.Context) Report) GRPCOption {
+	return func(s *GRPCServer) {
+		s.funcs[service] = fn
+	}
+}
+
+// WithWatchInterval sets how often Watch checks for status changes. The
+// checks for a service are shared by all Watch calls for it. The default is 5
+// seconds, which is also used if d is not positive.
+func WithWatchInterval(d time.Duration) GRPCOption {
+	return func(s *GRPCServer) {
+		s.watchInterval = d
+	}
+}
+
+// WithGRPCCheckOptions sets the options used when running checkers.
+func WithGRPCCheckOptions(opts ...Option) GRPCOption {
+	return func(s *GRPCServer) {
+		s.checkOpts = opts
+	}
+}
+
+// GRPCServer implements the gRPC Health Checking Protocol, grpc.health.v1,
+// with Checkers. The empty service name reports the overall health of all
+// checkers. Other service names are configured with WithService and
+// WithServiceFunc.
+//
+// A service is SERVING when its report is healthy, including when it is
+// degraded, and NOT_SERVING otherwise.
+type GRPCServer struct {
+	healthpb.UnimplementedHealthServer
+
+	logger        log.Logger
+	checkers      map[string]Checker
+	groups        map[string][]string
+	funcs         map[string]func(context.Context) Report
+	watchInterval time.Duration
+	checkOpts     []Option
+
+	mu      sync.Mutex
+	watches map[string]*watch
+}
+
+// watch polls the status of a service for all Watch calls for it.
+type watch struct {
+	refs    int
+	cancel  context.CancelFunc
+	status  healthpb.HealthCheckResponse_ServingStatus
+	changed chan struct{} // closed when status changes
+}
+
+// NewGRPCServer creates a GRPCServer for checkers. Failed checks are logged
+// to logger by Check and List, and by Watch when the status of a service
+// changes.
+func NewGRPCServer(logger log.Logger, checkers map[string]Checker, opts ...GRPCOption) *GRPCServer {
+	s := &GRPCServer{
+		logger:        logger,
+		checkers:      checkers,
+		groups:        make(map[string][]string),
+		funcs:         make(map[string]func(context.Context) Report),
+		watchInterval: 5 * time.Second,
+		watches:       make(map[string]*watch),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.watchInterval <= 0 {
+		s.watchInterval = 5 * time.Second
+	}
+	for service, names := range s.groups {
+		if len(names) == 0 {
+			panic(fmt.Sprintf("health: no checkers for gRPC service %q", service))
+		}
+		for _, name := range names {
+			if _, ok := s.checkers[name]; !ok {
+				panic(fmt.Sprintf("health: unknown checker %q for gRPC service %q", name, service))
+			}
+		}
+	}
+	return s
+}
+
+// Register registers the health service with a gRPC server.
+func (s *GRPCServer) Register(srv grpc.ServiceRegistrar) {
+	healthpb.RegisterHealthServer(srv, s)
+}
+
+// Check implements grpc.health.v1.Health/Check.
+func (s *GRPCServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.status(ctx, req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// List implements grpc.health.v1.Health/List.
+func (s *GRPCServer) List(ctx context.Context, req *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	resp := &healthpb.HealthListResponse{
+		Statuses: make(map[string]*healthpb.HealthCheckResponse),
+	}
+	for _, service := range s.services() {
+		st, _ := s.status(ctx, service)
+		resp.Statuses[service] = &healthpb.HealthCheckResponse{Status: st}
+	}
+	return resp, nil
+}
+
+// Watch implements grpc.health.v1.Health/Watch. It sends the current status
+// of the service, and then a new message whenever the status changes.
+// Unknown services are reported as SERVICE_UNKNOWN, without ending the call.
+func (s *GRPCServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	ctx := stream.Context()
+	w := s.subscribe(req.GetService())
+	defer s.unsubscribe(req.GetService(), w)
+
+	last := unknownStatus
+	for {
+		s.mu.Lock()
+		st, changed := w.status, w.changed
+		s.mu.Unlock()
+
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-changed:
+		}
+	}
+}
+
+// unknownStatus is the status of a watch before its first poll.
+const unknownStatus = healthpb.HealthCheckResponse_ServingStatus(-1)
+
+// subscribe returns the watch for service, starting to poll it if there is
+// none yet.
+func (s *GRPCServer) subscribe(service string) *watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.watches[service]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		w = &watch{cancel: cancel, status: unknownStatus, changed: make(chan struct{})}
+		s.watches[service] = w
+		go s.poll(ctx, service, w)
+	}
+	w.refs++
+	return w
+}
+
+// unsubscribe stops polling service once w has no more subscribers.
+func (s *GRPCServer) unsubscribe(service string, w *watch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.refs--
+	if w.refs == 0 {
+		w.cancel()
+		delete(s.watches, service)
+	}
+}
+
+// poll updates the status of w every watch interval until ctx is cancelled.
+// Failed checks are only logged when the status changes.
+func (s *GRPCServer) poll(ctx context.Context, service string, w *watch) {
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		s.mu.Lock()
+		last := w.status
+		s.mu.Unlock()
+
+		report, ok := s.report(ctx, service)
+		st := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if ok {
+			st = servingStatus(report)
+		}
+		if st != last && ctx.Err() == nil {
+			logReport(log.With(s.logger, "grpc_service", service), report)
+			s.mu.Lock()
+			w.status = st
+			close(w.changed)
+			w.changed = make(chan struct{})
+			s.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// status runs the checkers for service and logs failed checks, reporting
+// false for unknown services.
+func (s *GRPCServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	report, ok := s.report(ctx, service)
+	if !ok {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	logReport(log.With(s.logger, "grpc_service", service), report)
+	return servingStatus(report), true
+}
+
+// report runs the checkers for service, reporting false for unknown services.
+func (s *GRPCServer) report(ctx context.Context, service string) (Report, bool) {
+	if fn, ok := s.funcs[service]; ok {
+		return fn(ctx), true
+	}
+	names, ok := s.groups[service]
+	if !ok && service != "" {
+		return Report{}, false
+	}
+	checkers := s.checkers
+	if service != "" {
+		checkers = make(map[string]Checker, len(names))
+		for _, name := range names {
+			checkers[name] = s.checkers[name]
+		}
+	}
+	return Check(ctx, checkers, s.checkOpts...), true
+}
+
+func servingStatus(report Report) healthpb.HealthCheckResponse_ServingStatus {
+	if !report.Healthy() {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// services returns the known service names, sorted.
+func (s *GRPCServer) services() []string {
+	known := map[string]bool{"": true}
+	for name := range s.groups {
+		known[name] = true
+	}
+	for name := range s.funcs {
+		known[name] = true
+	}
+	services := make([]string, 0, len(known))
+	for name := range known {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services
+}
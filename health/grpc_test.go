@@ -0,0 +1,190 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kolide/kit/logutil/logtest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newGRPCHealthClient(t *testing.T, s *GRPCServer) healthpb.HealthClient {
+	t.Helper()
+
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	s.Register(srv)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestGRPCServerCheck(t *testing.T) {
+	t.Parallel()
+
+	probes := NewProbes(log.NewNopLogger())
+	probes.Add(Liveness, "loop", Nop())
+
+	client := newGRPCHealthClient(t, NewGRPCServer(log.NewNopLogger(),
+		map[string]Checker{
+			"db":    fail{},
+			"cache": Nop(),
+		},
+		WithService("api.Cache", "cache"),
+		WithService("api.Store", "db", "cache"),
+		WithServiceFunc("liveness", func(ctx context.Context) Report {
+			return probes.Check(ctx, Liveness)
+		}),
+	))
+
+	var tests = []struct {
+		service string
+		want    healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"", healthpb.HealthCheckResponse_NOT_SERVING},
+		{"api.Cache", healthpb.HealthCheckResponse_SERVING},
+		{"api.Store", healthpb.HealthCheckResponse_NOT_SERVING},
+		{"liveness", healthpb.HealthCheckResponse_SERVING},
+	}
+	for _, tt := range tests {
+		resp, err := client.Check(t.Context(), &healthpb.HealthCheckRequest{Service: tt.service})
+		require.NoError(t, err)
+		require.Equal(t, tt.want, resp.GetStatus(), tt.service)
+	}
+
+	_, err := client.Check(t.Context(), &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := client.List(t.Context(), &healthpb.HealthListRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetStatuses(), 4)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, list.GetStatuses()["api.Cache"].GetStatus())
+}
+
+func TestGRPCServerWatch(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+	client := newGRPCHealthClient(t, NewGRPCServer(log.NewNopLogger(),
+		map[string]Checker{
+			"db": CheckerFunc(func() error {
+				if failing.Load() {
+					return errors.New("db down")
+				}
+				return nil
+			}),
+		},
+		WithWatchInterval(5*time.Millisecond),
+	))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	failing.Store(true)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	failing.Store(false)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// unknown services are reported without ending the call
+	stream, err = client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	require.NoError(t, err)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, resp.GetStatus())
+}
+
+func TestGRPCServerWatchShared(t *testing.T) {
+	t.Parallel()
+
+	logger := logtest.New()
+	s := NewGRPCServer(logger, map[string]Checker{"db": fail{}}, WithWatchInterval(5*time.Millisecond))
+	client := newGRPCHealthClient(t, s)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	}
+
+	// both calls share one poller, which only logs when the status changes
+	s.mu.Lock()
+	require.Len(t, s.watches, 1)
+	require.Equal(t, 2, s.watches[""].refs)
+	s.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	logger.AssertCount(t, 1, "health-checker", "db")
+
+	// the poller stops once every call has ended
+	cancel()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		n := len(s.watches)
+		s.mu.Unlock()
+		if n == 0 {
+			break
+		}
+	}
+	s.mu.Lock()
+	require.Empty(t, s.watches)
+	s.mu.Unlock()
+}
+
+func TestNewGRPCServerInvalidService(t *testing.T) {
+	t.Parallel()
+
+	checkers := map[string]Checker{"db": Nop()}
+	require.Panics(t, func() { NewGRPCServer(log.NewNopLogger(), checkers, WithService("api.Store", "db", "cache")) })
+	require.Panics(t, func() { NewGRPCServer(log.NewNopLogger(), checkers, WithService("api.Store")) })
+	require.NotPanics(t, func() { NewGRPCServer(log.NewNopLogger(), checkers, WithService("api.Store", "db")) })
+}
+
+func TestGRPCServerInvalidWatchInterval(t *testing.T) {
+	t.Parallel()
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		s := NewGRPCServer(log.NewNopLogger(), map[string]Checker{"db": Nop()}, WithWatchInterval(d))
+		require.Equal(t, 5*time.Second, s.watchInterval)
+
+		client := newGRPCHealthClient(t, s)
+		stream, err := client.Watch(t.Context(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+}
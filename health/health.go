@@ -2,7 +2,11 @@
 package health
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"path"
+	"strconv"
 
 	"github.com/go-kit/kit/log"
 )
@@ -17,28 +21,69 @@ type Checker interface {
 // Handler responds with either:
 // 200 OK if the server can successfully communicate with it's backends or
 // 500 if any of the backends are reporting an issue.
-func Handler(logger log.Logger, checkers map[string]Checker) http.HandlerFunc {
+// Warnings and failures of checkers marked with NonCritical report the
+// status as degraded, but still respond with 200 OK.
+//
+// Checkers run concurrently, with the timeouts set by opts. The response body
+// is a JSON object with the overall status. When the request has the query
+// parameter verbose=true, the body also includes the status, error and
+// duration of each checker.
+func Handler(logger log.Logger, checkers map[string]Checker, opts ...Option) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		healthy := CheckHealth(logger, checkers)
-		if !healthy {
-			w.WriteHeader(http.StatusInternalServerError)
+		report := Check(r.Context(), checkers, opts...)
+		logReport(logger, report)
+		writeReport(w, r, report)
+	}
+}
+
+// CheckHandler returns an http.Handler which runs a single checker, named by
+// the last element of the request path, and responds like Handler.
+// It responds with 404 Not Found for unknown checkers. It is intended to be
+// mounted on a subtree:
+//
+//	mux.Handle("/healthz/", health.CheckHandler(logger, checkers))
+func CheckHandler(logger log.Logger, checkers map[string]Checker, opts ...Option) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+		hc, ok := checkers[name]
+		if !ok {
+			http.Error(w, "unknown health check "+strconv.Quote(name), http.StatusNotFound)
 			return
 		}
+		report := Check(r.Context(), map[string]Checker{name: hc}, opts...)
+		logReport(logger, report)
+		writeReport(w, r, report)
 	}
 }
 
+func writeReport(w http.ResponseWriter, r *http.Request, report Report) {
+	if verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose")); !verbose {
+		report.Checks = nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Healthy() {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
 // CheckHealth checks multiple checkers returning false if any of them fail.
 // CheckHealth logs the reason a checker fails.
+// Checkers run concurrently with the default timeouts, use Check to configure
+// them.
 func CheckHealth(logger log.Logger, checkers map[string]Checker) bool {
-	healthy := true
-	for name, hc := range checkers {
-		if err := hc.HealthCheck(); err != nil {
-			log.With(logger, "component", "healthz").Log("err", err, "health-checker", name)
-			healthy = false
-			continue
-		}
+	report := Check(context.Background(), checkers)
+	logReport(logger, report)
+	return report.Healthy()
+}
+
+// logReport logs the reason each failed checker in report failed.
+func logReport(logger log.Logger, report Report) {
+	logger = log.With(logger, "component", "healthz")
+	for _, name := range report.Failed() {
+		res := report.Checks[name]
+		logger.Log("err", res.Error, "health-checker", name, "critical", res.Critical, "duration", res.Duration)
 	}
-	return healthy
 }
 
 // Nop creates a noop checker. Useful in tests.
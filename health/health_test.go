@@ -1,12 +1,15 @@
 package health
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/kolide/kit/logutil/logtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -75,3 +78,95 @@ type healthcheckFunc func() error
 func (fn healthcheckFunc) HealthCheck() error {
 	return fn()
 }
+
+func TestCheckTimeouts(t *testing.T) {
+	t.Parallel()
+
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+	hang := healthcheckFunc(func() error {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	report := Check(t.Context(), map[string]Checker{
+		"hang": hang,
+		"pass": Nop(),
+		"fail": fail{},
+	}, WithCheckTimeout(50*time.Millisecond))
+	require.True(t, time.Since(start) < time.Second, "checks must run concurrently and time out")
+	require.Equal(t, StatusFail, report.Status)
+	require.Equal(t, []string{"fail", "hang"}, report.Failed())
+	require.Contains(t, report.Checks["hang"].Error.Error(), "timed out")
+	require.Equal(t, StatusPass, report.Checks["pass"].Status)
+
+	report = Check(t.Context(), map[string]Checker{"hang": hang},
+		WithCheckTimeout(time.Minute), WithTimeout(50*time.Millisecond))
+	require.Equal(t, StatusFail, report.Checks["hang"].Status)
+
+	report = Check(t.Context(), map[string]Checker{
+		"panic": healthcheckFunc(func() error { panic("boom") }),
+	})
+	require.Contains(t, report.Checks["panic"].Error.Error(), "boom")
+}
+
+func TestHandlerReport(t *testing.T) {
+	t.Parallel()
+
+	checkers := map[string]Checker{
+		"pass": Nop(),
+		"fail": fail{},
+	}
+	logger := logtest.New()
+	handler := Handler(logger, checkers)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequestWithContext(t.Context(), "GET", "/healthz", nil))
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"status":"fail"}`, rr.Body.String())
+	logger.AssertLogged(t, "health-checker", "fail")
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequestWithContext(t.Context(), "GET", "/healthz?verbose=true", nil))
+	var body struct {
+		Status string
+		Checks map[string]struct {
+			Status   string
+			Error    string
+			Duration string
+		}
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, "fail", body.Status)
+	require.Equal(t, "pass", body.Checks["pass"].Status)
+	require.Empty(t, body.Checks["pass"].Error)
+	require.Equal(t, "fail", body.Checks["fail"].Error)
+	_, err := time.ParseDuration(body.Checks["fail"].Duration)
+	require.NoError(t, err)
+}
+
+func TestCheckHandler(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/healthz/", CheckHandler(log.NewNopLogger(), map[string]Checker{
+		"pass": Nop(),
+		"fail": fail{},
+	}))
+
+	var tests = []struct {
+		path       string
+		wantHeader int
+	}{
+		{"/healthz/pass", http.StatusOK},
+		{"/healthz/fail", http.StatusInternalServerError},
+		{"/healthz/missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequestWithContext(t.Context(), "GET", tt.path, nil))
+		assert.Equal(t, tt.wantHeader, rr.Code, tt.path)
+	}
+}
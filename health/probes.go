@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Group identifies the probe a checker affects.
+type Group string
+
+// Probe groups, matching the Kubernetes container probes.
+const (
+	// Liveness checkers detect a process which can only recover by being
+	// restarted. Downstream dependencies should not be liveness checkers.
+	Liveness Group = "liveness"
+
+	// Readiness checkers detect a process which should temporarily not
+	// receive traffic, like one whose database is unavailable.
+	Readiness Group = "readiness"
+
+	// Startup checkers detect a process which has finished starting. They
+	// only need to pass once.
+	Startup Group = "startup"
+)
+
+// Names of the synthetic checks added to readiness reports.
+const (
+	drainCheck   = "drain"
+	startupCheck = "startup"
+)
+
+var (
+	errDraining   = errors.New("draining")
+	errNotStarted = errors.New("startup checks have not passed")
+)
+
+// Probes holds checkers separated into liveness, readiness and startup groups,
+// so that a failing dependency takes a process out of rotation instead of
+// restarting it. It is safe for concurrent use.
+//
+// Readiness fails until the startup checks have passed, and while readiness
+// is disabled with SetReady.
+type Probes struct {
+	logger log.Logger
+	opts   []Option
+
+	mu       sync.Mutex
+	checkers map[Group]map[string]Checker
+	started  bool
+	draining bool
+}
+
+// NewProbes creates an empty set of probes. Failed checks are logged to
+// logger, and opts configure the timeouts used when running checks.
+func NewProbes(logger log.Logger, opts ...Option) *Probes {
+	return &Probes{
+		logger:   logger,
+		opts:     opts,
+		checkers: make(map[Group]map[string]Checker),
+	}
+}
+
+// Add registers a checker in group g. A checker may be added to several
+// groups. Readiness reports include the synthetic checks "startup" and
+// "drain", so Add panics if either name is used for a readiness checker.
+func (p *Probes) Add(g Group, name string, hc Checker) {
+	if g == Readiness && (name == startupCheck || name == drainCheck) {
+		panic(fmt.Sprintf("health: readiness check name %q is reserved", name))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.checkers[g] == nil {
+		p.checkers[g] = make(map[string]Checker)
+	}
+	p.checkers[g][name] = hc
+}
+
+// SetReady sets whether the process is willing to receive traffic. Calling
+// SetReady(false) fails readiness regardless of the checkers, so the process
+// can drain connections before shutting down. Processes are ready by default.
+func (p *Probes) SetReady(ready bool) {
+	p.mu.Lock()
+	p.draining = !ready
+	p.mu.Unlock()
+}
+
+// Check runs the checkers in group g and returns a report of their results.
+func (p *Probes) Check(ctx context.Context, g Group) Report {
+	switch g {
+	case Startup:
+		return p.checkStartup(ctx)
+	case Readiness:
+		return p.checkReadiness(ctx)
+	default:
+		return Check(ctx, p.group(g), p.opts...)
+	}
+}
+
+func (p *Probes) checkStartup(ctx context.Context) Report {
+	p.mu.Lock()
+	started := p.started
+	p.mu.Unlock()
+	if started {
+		return Report{Status: StatusPass}
+	}
+
+	report := Check(ctx, p.group(Startup), p.opts...)
+	if report.Healthy() {
+		p.mu.Lock()
+		p.started = true
+		p.mu.Unlock()
+	}
+	return report
+}
+
+func (p *Probes) checkReadiness(ctx context.Context) Report {
+	report := Check(ctx, p.group(Readiness), p.opts...)
+	if !p.checkStartup(ctx).Healthy() {
+		report.Checks[startupCheck] = CheckResult{Result: Fail(errNotStarted), Critical: true}
+	}
+
+	p.mu.Lock()
+	draining := p.draining
+	p.mu.Unlock()
+	if draining {
+		report.Checks[drainCheck] = CheckResult{Result: Fail(errDraining), Critical: true}
+	}
+	report.Status = aggregate(report.Checks)
+	return report
+}
+
+// group returns a copy of the checkers in g, so they can be run without
+// holding the lock.
+func (p *Probes) group(g Group) map[string]Checker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	checkers := make(map[string]Checker, len(p.checkers[g]))
+	for name, hc := range p.checkers[g] {
+		checkers[name] = hc
+	}
+	return checkers
+}
+
+// Handler returns an http.Handler which runs the checkers in group g and
+// responds like the package level Handler.
+//
+//	mux.Handle("/livez", probes.Handler(health.Liveness))
+//	mux.Handle("/readyz", probes.Handler(health.Readiness))
+//	mux.Handle("/startupz", probes.Handler(health.Startup))
+func (p *Probes) Handler(g Group) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := p.Check(r.Context(), g)
+		logReport(log.With(p.logger, "probe", g), report)
+		writeReport(w, r, report)
+	})
+}
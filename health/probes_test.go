@@ -0,0 +1,80 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		started  atomic.Bool
+		startups atomic.Int32
+		dbDown   atomic.Bool
+	)
+	dbDown.Store(true)
+
+	p := NewProbes(log.NewNopLogger())
+	p.Add(Liveness, "loop", Nop())
+	p.Add(Readiness, "db", healthcheckFunc(func() error {
+		if dbDown.Load() {
+			return errors.New("db down")
+		}
+		return nil
+	}))
+	p.Add(Startup, "migrations", healthcheckFunc(func() error {
+		startups.Add(1)
+		if !started.Load() {
+			return errors.New("migrating")
+		}
+		return nil
+	}))
+
+	status := func(g Group) int {
+		rr := httptest.NewRecorder()
+		p.Handler(g).ServeHTTP(rr, httptest.NewRequestWithContext(t.Context(), "GET", "/", nil))
+		return rr.Code
+	}
+
+	// a failing dependency affects readiness but not liveness
+	require.Equal(t, http.StatusOK, status(Liveness))
+	require.Equal(t, http.StatusInternalServerError, status(Startup))
+	report := p.Check(t.Context(), Readiness)
+	require.Equal(t, []string{"db", "startup"}, report.Failed())
+
+	// startup checks only need to pass once
+	started.Store(true)
+	dbDown.Store(false)
+	require.Equal(t, http.StatusOK, status(Startup))
+	require.Equal(t, http.StatusOK, status(Readiness))
+	started.Store(false)
+	runs := startups.Load()
+	require.Equal(t, http.StatusOK, status(Startup))
+	require.Equal(t, http.StatusOK, status(Readiness))
+	require.Equal(t, runs, startups.Load())
+
+	// draining fails readiness only
+	p.SetReady(false)
+	require.Equal(t, http.StatusInternalServerError, status(Readiness))
+	require.Equal(t, []string{"drain"}, p.Check(t.Context(), Readiness).Failed())
+	require.Equal(t, http.StatusOK, status(Liveness))
+	p.SetReady(true)
+	require.Equal(t, http.StatusOK, status(Readiness))
+}
+
+func TestProbesReservedNames(t *testing.T) {
+	t.Parallel()
+
+	p := NewProbes(log.NewNopLogger())
+	require.Panics(t, func() { p.Add(Readiness, "startup", Nop()) })
+	require.Panics(t, func() { p.Add(Readiness, "drain", Nop()) })
+	require.NotPanics(t, func() { p.Add(Liveness, "drain", Nop()) })
+	require.NotPanics(t, func() { p.Add(Startup, "startup", Nop()) })
+}
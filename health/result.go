@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"errors"
+)
+
+// Result is the outcome of a ContextChecker.
+type Result struct {
+	// Status is StatusPass, StatusWarn or StatusFail.
+	Status Status
+
+	// Message describes the status, like why a check is warning.
+	Message string
+
+	// Metadata holds details about the check, like pool sizes or latencies,
+	// which are included in verbose reports.
+	Metadata map[string]interface{}
+
+	// Error is the error which caused a failure, if any.
+	Error error
+}
+
+// Pass returns a passing Result.
+func Pass() Result {
+	return Result{Status: StatusPass}
+}
+
+// Warn returns a Result for a dependency which is degraded but serving.
+func Warn(msg string) Result {
+	return Result{Status: StatusWarn, Message: msg}
+}
+
+// Fail returns a failing Result caused by err.
+func Fail(err error) Result {
+	return Result{Status: StatusFail, Error: err}
+}
+
+// ContextChecker checks the health of a dependency, giving up when ctx is
+// done. Unlike Checker, it can report a dependency as degraded with
+// StatusWarn, and attach a message and metadata to the result.
+//
+// Checkers created by FromContextChecker can be used everywhere a Checker is
+// accepted in this package, and are run with their context based interface.
+type ContextChecker interface {
+	Check(ctx context.Context) Result
+}
+
+// ContextCheckerFunc adapts a function to the ContextChecker interface.
+type ContextCheckerFunc func(ctx context.Context) Result
+
+// Check calls fn.
+func (fn ContextCheckerFunc) Check(ctx context.Context) Result {
+	return fn(ctx)
+}
+
+// Adapt returns a ContextChecker for hc. If hc implements ContextChecker it
+// is returned unchanged, otherwise an error from hc fails the check.
+// HealthCheck does not accept a context, so the adapted check can't be
+// cancelled, only abandoned.
+func Adapt(hc Checker) ContextChecker {
+	if cc, ok := hc.(ContextChecker); ok {
+		return cc
+	}
+	return ContextCheckerFunc(func(context.Context) Result {
+		if err := hc.HealthCheck(); err != nil {
+			return Fail(err)
+		}
+		return Pass()
+	})
+}
+
+// FromContextChecker returns a Checker for cc, so it can be used with the
+// functions in this package which accept a map of Checkers.
+// HealthCheck only returns an error when cc fails; warnings are not errors.
+func FromContextChecker(cc ContextChecker) Checker {
+	return contextChecker{cc}
+}
+
+type contextChecker struct {
+	ContextChecker
+}
+
+func (c contextChecker) HealthCheck() error {
+	return resultError(c.Check(context.Background()))
+}
+
+// resultError returns the error for a failed result.
+func resultError(r Result) error {
+	switch {
+	case r.Status != StatusFail:
+		return nil
+	case r.Error != nil:
+		return r.Error
+	case r.Message != "":
+		return errors.New(r.Message)
+	default:
+		return errFailed
+	}
+}
+
+var errFailed = errors.New("health check failed")
+
+// NonCritical marks hc as non-critical. A failing non-critical checker
+// degrades the report instead of failing it, so probes keep passing while the
+// failure is still reported.
+func NonCritical(hc Checker) Checker {
+	return nonCritical{contextChecker{Adapt(hc)}}
+}
+
+type nonCritical struct {
+	contextChecker
+}
+
+// isCritical reports whether hc was not marked with NonCritical.
+func isCritical(hc Checker) bool {
+	_, ok := hc.(nonCritical)
+	return !ok
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCriticality(t *testing.T) {
+	t.Parallel()
+
+	warn := FromContextChecker(ContextCheckerFunc(func(context.Context) Result {
+		return Warn("replica lag is high")
+	}))
+
+	var tests = []struct {
+		name       string
+		checkers   map[string]Checker
+		wantStatus Status
+	}{
+		{
+			name:       "pass",
+			checkers:   map[string]Checker{"a": Nop(), "b": NonCritical(Nop())},
+			wantStatus: StatusPass,
+		},
+		{
+			name:       "warning",
+			checkers:   map[string]Checker{"a": Nop(), "b": warn},
+			wantStatus: StatusDegraded,
+		},
+		{
+			name:       "non-critical failure",
+			checkers:   map[string]Checker{"a": Nop(), "b": NonCritical(fail{})},
+			wantStatus: StatusDegraded,
+		},
+		{
+			name:       "critical failure",
+			checkers:   map[string]Checker{"a": fail{}, "b": NonCritical(fail{})},
+			wantStatus: StatusFail,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			report := Check(t.Context(), tt.checkers)
+			require.Equal(t, tt.wantStatus, report.Status)
+			require.Equal(t, tt.wantStatus != StatusFail, report.Healthy())
+		})
+	}
+
+	report := Check(t.Context(), map[string]Checker{"a": NonCritical(fail{})})
+	require.False(t, report.Checks["a"].Critical)
+	require.Equal(t, "fail", report.Checks["a"].Error.Error())
+}
+
+func TestContextCheckerCancellation(t *testing.T) {
+	t.Parallel()
+
+	cancelled := make(chan struct{})
+	hc := FromContextChecker(ContextCheckerFunc(func(ctx context.Context) Result {
+		<-ctx.Done()
+		close(cancelled)
+		return Fail(ctx.Err())
+	}))
+
+	report := Check(t.Context(), map[string]Checker{"slow": hc}, WithCheckTimeout(10*time.Millisecond))
+	require.Equal(t, StatusFail, report.Status)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the check context was not cancelled")
+	}
+}
+
+func TestAdapt(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, StatusPass, Adapt(Nop()).Check(t.Context()).Status)
+	res := Adapt(fail{}).Check(t.Context())
+	require.Equal(t, StatusFail, res.Status)
+	require.Equal(t, "fail", res.Error.Error())
+
+	cc := ContextCheckerFunc(func(context.Context) Result { return Warn("slow") })
+	hc := FromContextChecker(cc)
+	require.NoError(t, hc.HealthCheck(), "warnings are not errors")
+	require.Equal(t, StatusWarn, Adapt(hc).Check(t.Context()).Status)
+
+	hc = FromContextChecker(ContextCheckerFunc(func(context.Context) Result {
+		return Result{Status: StatusFail, Message: "no replicas"}
+	}))
+	require.Equal(t, errors.New("no replicas"), hc.HealthCheck())
+}
+
+func TestHandlerDegraded(t *testing.T) {
+	t.Parallel()
+
+	handler := Handler(log.NewNopLogger(), map[string]Checker{
+		"cache": NonCritical(fail{}),
+		"db": FromContextChecker(ContextCheckerFunc(func(context.Context) Result {
+			res := Warn("replica lag is high")
+			res.Metadata = map[string]interface{}{"lag": "5s"}
+			return res
+		})),
+	})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequestWithContext(t.Context(), "GET", "/healthz?verbose=1", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Status string
+		Checks map[string]struct {
+			Status   string
+			Message  string
+			Error    string
+			Critical bool
+			Metadata map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, "degraded", body.Status)
+	require.Equal(t, "fail", body.Checks["cache"].Status)
+	require.False(t, body.Checks["cache"].Critical)
+	require.Equal(t, "warn", body.Checks["db"].Status)
+	require.Equal(t, "replica lag is high", body.Checks["db"].Message)
+	require.Equal(t, "5s", body.Checks["db"].Metadata["lag"])
+	require.True(t, body.Checks["db"].Critical)
+}
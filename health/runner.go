@@ -0,0 +1,252 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+var (
+	errNotRun     = errors.New("health check has not run yet")
+	errRecovering = errors.New("health check is recovering")
+)
+
+// RunnerOption configures a Runner.
+type RunnerOption func(*Runner)
+
+// WithInterval sets how often the Runner runs its checkers.
+// The default is 10 seconds, which is also used if d is not positive.
+func WithInterval(d time.Duration) RunnerOption {
+	return func(r *Runner) {
+		r.interval = d
+	}
+}
+
+// WithFailureThreshold sets the number of consecutive failures after which a
+// healthy checker is reported as failed. The default is 3.
+func WithFailureThreshold(n int) RunnerOption {
+	return func(r *Runner) {
+		r.failureThreshold = n
+	}
+}
+
+// WithSuccessThreshold sets the number of consecutive passes after which a
+// failed checker is reported as healthy again. The default is 1.
+func WithSuccessThreshold(n int) RunnerOption {
+	return func(r *Runner) {
+		r.successThreshold = n
+	}
+}
+
+// WithCheckOptions sets the options used when running the checkers.
+func WithCheckOptions(opts ...Option) RunnerOption {
+	return func(r *Runner) {
+		r.checkOpts = opts
+	}
+}
+
+// WithStateChangeFunc registers fn to be called when the reported status of a
+// checker changes. res is the result which caused the change. fn is called
+// from the Runner's goroutine, so it should not block.
+func WithStateChangeFunc(fn func(name string, from, to Status, res CheckResult)) RunnerOption {
+	return func(r *Runner) {
+		r.onChange = append(r.onChange, fn)
+	}
+}
+
+// Runner runs checkers in the background on an interval and caches their
+// results, so probes are cheap and don't load the dependencies being checked.
+//
+// To avoid flapping, a checker's reported status only changes after a number
+// of consecutive results agree on a new status, see WithFailureThreshold and
+// WithSuccessThreshold. The status of each checker is decided by its first
+// result. Until then the checker is reported as failed.
+type Runner struct {
+	logger           log.Logger
+	checkers         map[string]Checker
+	interval         time.Duration
+	failureThreshold int
+	successThreshold int
+	checkOpts        []Option
+	onChange         []func(name string, from, to Status, res CheckResult)
+
+	mu     sync.Mutex
+	states map[string]*checkState
+}
+
+type checkState struct {
+	status      Status
+	last        CheckResult
+	pending     Status // the status of the latest results, if not status
+	consecutive int    // number of consecutive results with pending status
+}
+
+// NewRunner creates a Runner for checkers. State changes are logged to
+// logger. Call Run to start running the checkers.
+func NewRunner(logger log.Logger, checkers map[string]Checker, opts ...RunnerOption) *Runner {
+	r := &Runner{
+		logger:           log.With(logger, "component", "healthz"),
+		checkers:         checkers,
+		interval:         10 * time.Second,
+		failureThreshold: 3,
+		successThreshold: 1,
+		states:           make(map[string]*checkState),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.interval <= 0 {
+		r.interval = 10 * time.Second
+	}
+	return r
+}
+
+// Run runs the checkers immediately and then on every interval, until ctx is
+// cancelled. It returns the error from ctx.
+func (r *Runner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs the checkers a single time and updates the cached results.
+func (r *Runner) RunOnce(ctx context.Context) {
+	report := Check(ctx, r.checkers, r.checkOpts...)
+	for name, res := range report.Checks {
+		r.update(name, res)
+	}
+}
+
+func (r *Runner) update(name string, res CheckResult) {
+	r.mu.Lock()
+	st, ok := r.states[name]
+	if !ok {
+		r.states[name] = &checkState{status: res.Status, last: res}
+		r.mu.Unlock()
+		return
+	}
+	st.last = res
+	if res.Status == st.status {
+		st.consecutive = 0
+		r.mu.Unlock()
+		return
+	}
+
+	if res.Status != st.pending {
+		st.pending = res.Status
+		st.consecutive = 0
+	}
+	st.consecutive++
+	threshold := r.successThreshold
+	if statusRank(res.Status) > statusRank(st.status) {
+		threshold = r.failureThreshold
+	}
+	if st.consecutive < threshold {
+		r.mu.Unlock()
+		return
+	}
+	from := st.status
+	st.status = res.Status
+	st.consecutive = 0
+	r.mu.Unlock()
+
+	r.logger.Log("msg", "health check changed status", "health-checker", name, "from", from, "to", res.Status, "err", res.Error)
+	for _, fn := range r.onChange {
+		fn(name, from, res.Status, res)
+	}
+}
+
+// Report returns the cached results. The status of each check is its reported
+// status after hysteresis, while the error and duration are from its latest
+// result.
+func (r *Runner) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checks := make(map[string]CheckResult, len(r.checkers))
+	for name, hc := range r.checkers {
+		checks[name] = r.cached(name, hc)
+	}
+	return Report{
+		Status: aggregate(checks),
+		Checks: checks,
+	}
+}
+
+// cached returns the cached result for the named checker.
+// It must be called with r.mu held.
+func (r *Runner) cached(name string, hc Checker) CheckResult {
+	st, ok := r.states[name]
+	if !ok {
+		return CheckResult{Result: Fail(errNotRun), Critical: isCritical(hc)}
+	}
+	res := st.last
+	if st.status != res.Status {
+		// the latest result has not changed the reported status yet
+		res.Message = ""
+		res.Error = nil
+		if st.status == StatusFail {
+			res.Error = errRecovering
+		}
+		res.Status = st.status
+	}
+	return res
+}
+
+// statusRank orders check statuses from best to worst.
+func statusRank(s Status) int {
+	switch s {
+	case StatusPass:
+		return 0
+	case StatusWarn:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Checker returns a Checker which reports the cached status of the named
+// checker, so cached results can be used with Handler or Probes. The returned
+// Checker also implements ContextChecker, and is non-critical if the named
+// checker is.
+func (r *Runner) Checker(name string) Checker {
+	var hc Checker = cachedChecker{runner: r, name: name}
+	if !isCritical(r.checkers[name]) {
+		hc = NonCritical(hc)
+	}
+	return hc
+}
+
+// Handler returns an http.Handler which responds with the cached results,
+// like the package level Handler.
+func (r *Runner) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeReport(w, req, r.Report())
+	})
+}
+
+type cachedChecker struct {
+	runner *Runner
+	name   string
+}
+
+func (c cachedChecker) Check(context.Context) Result {
+	c.runner.mu.Lock()
+	defer c.runner.mu.Unlock()
+	return c.runner.cached(c.name, c.runner.checkers[c.name]).Result
+}
+
+func (c cachedChecker) HealthCheck() error {
+	return resultError(c.Check(context.Background()))
+}
@@ -0,0 +1,163 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kolide/kit/logutil/logtest"
+	"github.com/stretchr/testify/require"
+)
+
+type transition struct {
+	name     string
+	from, to Status
+}
+
+func TestRunnerHysteresis(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+	var transitions []transition
+	logger := logtest.New()
+	r := NewRunner(logger, map[string]Checker{
+		"db": healthcheckFunc(func() error {
+			if failing.Load() {
+				return errors.New("db down")
+			}
+			return nil
+		}),
+	},
+		WithFailureThreshold(3),
+		WithSuccessThreshold(2),
+		WithStateChangeFunc(func(name string, from, to Status, res CheckResult) {
+			transitions = append(transitions, transition{name, from, to})
+		}),
+	)
+
+	// checks are failed until they have run
+	require.Equal(t, StatusFail, r.Report().Status)
+	require.Equal(t, errNotRun, r.Checker("db").HealthCheck())
+
+	var runs = []struct {
+		fail       bool
+		wantStatus Status
+	}{
+		{false, StatusPass}, // the first result decides the status
+		{true, StatusPass},
+		{true, StatusPass},
+		{false, StatusPass}, // a pass resets the failure count
+		{true, StatusPass},
+		{true, StatusPass},
+		{true, StatusFail},
+		{false, StatusFail},
+		{true, StatusFail}, // a failure resets the success count
+		{false, StatusFail},
+		{false, StatusPass},
+	}
+	for i, run := range runs {
+		failing.Store(run.fail)
+		r.RunOnce(context.Background())
+		require.Equal(t, run.wantStatus, r.Report().Checks["db"].Status, "run %d", i)
+	}
+
+	require.Equal(t, []transition{
+		{"db", StatusPass, StatusFail},
+		{"db", StatusFail, StatusPass},
+	}, transitions)
+	logger.AssertCount(t, 2, "msg", "health check changed status")
+	require.NoError(t, r.Checker("db").HealthCheck())
+}
+
+func TestRunnerMixedResults(t *testing.T) {
+	t.Parallel()
+
+	var transitions []transition
+	r := NewRunner(logtest.New(), map[string]Checker{"db": healthcheckFunc(func() error { return nil })},
+		WithFailureThreshold(2),
+		WithSuccessThreshold(2),
+		WithStateChangeFunc(func(name string, from, to Status, res CheckResult) {
+			transitions = append(transitions, transition{name, from, to})
+		}),
+	)
+
+	warn := CheckResult{Result: Warn("slow")}
+	fail := CheckResult{Result: Fail(errors.New("db down"))}
+	pass := CheckResult{Result: Pass()}
+
+	var runs = []struct {
+		res        CheckResult
+		wantStatus Status
+	}{
+		{pass, StatusPass},
+		{warn, StatusPass},
+		{fail, StatusPass}, // a different status restarts the count
+		{warn, StatusPass},
+		{fail, StatusPass},
+		{fail, StatusFail},
+		{warn, StatusFail},
+		{pass, StatusFail}, // a pass does not count towards a warning
+		{warn, StatusFail},
+		{warn, StatusWarn}, // fail to warn uses the success threshold
+	}
+	for i, run := range runs {
+		r.update("db", run.res)
+		require.Equal(t, run.wantStatus, r.Report().Checks["db"].Status, "run %d", i)
+	}
+
+	require.Equal(t, []transition{
+		{"db", StatusPass, StatusFail},
+		{"db", StatusFail, StatusWarn},
+	}, transitions)
+}
+
+func TestRunnerRun(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	r := NewRunner(logtest.New(), map[string]Checker{
+		"pass": healthcheckFunc(func() error {
+			calls.Add(1)
+			return nil
+		}),
+	}, WithInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.True(t, calls.Load() >= 3, "checks must run on every interval")
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, httptest.NewRequestWithContext(t.Context(), "GET", "/healthz", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"status":"pass"}`, rr.Body.String())
+
+	// cached checkers don't run the underlying checker
+	n := calls.Load()
+	require.True(t, CheckHealth(logtest.New(), map[string]Checker{"pass": r.Checker("pass")}))
+	require.Equal(t, n, calls.Load())
+}
+
+func TestRunnerInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		r := NewRunner(logtest.New(), map[string]Checker{"db": Nop()}, WithInterval(d))
+		require.Equal(t, 10*time.Second, r.interval)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Equal(t, context.Canceled, r.Run(ctx))
+	}
+}
@@ -0,0 +1,76 @@
+package logutil
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kolide/kit/contexts/tracecontext"
+	"github.com/kolide/kit/contexts/uuid"
+	"go.opencensus.io/trace"
+)
+
+// Use a private type to prevent name collisions with other packages.
+type key string
+
+const (
+	loggerKey key = "logger"
+	fieldsKey key = "fields"
+)
+
+// NewContext creates a new context carrying logger.
+func NewContext(ctx context.Context, logger log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// WithFields creates a new context carrying keyvals in addition to any fields
+// already stored in ctx. The fields are added to loggers returned by
+// FromContext and WithContext.
+func WithFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	existing, _ := ctx.Value(fieldsKey).([]interface{})
+	fields := make([]interface{}, 0, len(existing)+len(keyvals))
+	fields = append(fields, existing...)
+	fields = append(fields, keyvals...)
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, decorated by
+// WithContext. If ctx does not carry a logger, a nop logger is used.
+func FromContext(ctx context.Context) log.Logger {
+	logger, ok := ctx.Value(loggerKey).(log.Logger)
+	if !ok {
+		logger = log.NewNopLogger()
+	}
+	return WithContext(ctx, logger)
+}
+
+// WithContext returns a logger which adds the request values found in ctx
+// to every record: the request UUID from the contexts/uuid package as "uuid",
+// the OpenCensus trace and span IDs as "trace_id" and "span_id", and any
+// fields attached with WithFields. Without an OpenCensus span, the W3C trace
+// context from the contexts/tracecontext package is used for the trace IDs.
+func WithContext(ctx context.Context, logger log.Logger) log.Logger {
+	var keyvals []interface{}
+	if id, ok := uuid.FromContext(ctx); ok && id != "" {
+		keyvals = append(keyvals, "uuid", id)
+	}
+	if span := trace.FromContext(ctx); span != nil {
+		sc := span.SpanContext()
+		keyvals = append(keyvals,
+			"trace_id", sc.TraceID.String(),
+			"span_id", sc.SpanID.String(),
+		)
+	} else if tc, ok := tracecontext.FromContext(ctx); ok {
+		keyvals = append(keyvals,
+			"trace_id", tc.Parent.TraceID.String(),
+			"span_id", tc.Parent.ParentID.String(),
+		)
+	}
+	if fields, ok := ctx.Value(fieldsKey).([]interface{}); ok {
+		keyvals = append(keyvals, fields...)
+	}
+
+	if len(keyvals) == 0 {
+		return logger
+	}
+	return log.With(logger, keyvals...)
+}
@@ -0,0 +1,65 @@
+package logutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/kit/contexts/tracecontext"
+	"github.com/kolide/kit/contexts/uuid"
+	"github.com/kolide/kit/logutil/logtest"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	rec := logtest.New()
+	ctx := NewContext(context.Background(), rec)
+	ctx = uuid.NewContext(ctx, "abc-123")
+	ctx = WithFields(ctx, "tenant", "acme")
+	ctx = WithFields(ctx, "actor", "alice")
+	ctx, span := trace.StartSpan(ctx, "test", trace.WithSampler(trace.AlwaysSample()))
+	defer span.End()
+
+	require.NoError(t, FromContext(ctx).Log("msg", "hello"))
+	require.Len(t, rec.Records(), 1)
+
+	sc := span.SpanContext()
+	require.Equal(t, logtest.Record{
+		"uuid", "abc-123",
+		"trace_id", sc.TraceID.String(),
+		"span_id", sc.SpanID.String(),
+		"tenant", "acme",
+		"actor", "alice",
+		"msg", "hello",
+	}, rec.Records()[0])
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	t.Parallel()
+
+	logger := FromContext(context.Background())
+	require.NotNil(t, logger)
+	require.NoError(t, logger.Log("msg", "dropped"))
+
+	rec := logtest.New()
+	require.NoError(t, WithContext(context.Background(), rec).Log("msg", "plain"))
+	require.Equal(t, logtest.Record{"msg", "plain"}, rec.Records()[0])
+}
+
+func TestFromContextTraceContext(t *testing.T) {
+	t.Parallel()
+
+	tp, err := tracecontext.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+
+	rec := logtest.New()
+	ctx := tracecontext.NewContext(context.Background(), tracecontext.TraceContext{Parent: tp})
+	require.NoError(t, WithContext(ctx, rec).Log("msg", "hello"))
+	require.Equal(t, logtest.Record{
+		"trace_id", "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id", "00f067aa0ba902b7",
+		"msg", "hello",
+	}, rec.Records()[0])
+}
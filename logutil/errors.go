@@ -0,0 +1,184 @@
+package logutil
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/go-kit/kit/log"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// ErrorOption configures how errors are expanded into log fields.
+type ErrorOption func(*errorExpander)
+
+// WithMaxStackFrames limits the number of stack frames logged for an error.
+// The default is 16.
+func WithMaxStackFrames(n int) ErrorOption {
+	return func(e *errorExpander) {
+		e.maxFrames = n
+	}
+}
+
+// WithMaxCauses limits the number of causes logged for an error.
+// The default is 10.
+func WithMaxCauses(n int) ErrorOption {
+	return func(e *errorExpander) {
+		e.maxCauses = n
+	}
+}
+
+// WithRuntimeStack logs the stack of the caller when an error does not carry
+// a stack trace from github.com/pkg/errors.
+func WithRuntimeStack() ErrorOption {
+	return func(e *errorExpander) {
+		e.runtimeStack = true
+	}
+}
+
+type errorExpander struct {
+	maxFrames    int
+	maxCauses    int
+	runtimeStack bool
+}
+
+func newErrorExpander(opts []ErrorOption) *errorExpander {
+	e := &errorExpander{
+		maxFrames: 16,
+		maxCauses: 10,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// NewErrorLogger wraps logger, expanding every error value in a record into
+// structured fields. For an error logged with the key "err" the record will
+// contain:
+//
+//	err         the error message
+//	err.causes  the messages of the wrapped errors, outermost first
+//	err.types   the type names of the error and each wrapped error
+//	err.stack   a compact stack trace, innermost frame first
+//
+// Errors are unwrapped through both the standard library Unwrap method and
+// the Cause method used by github.com/pkg/errors. The stack trace is taken
+// from the innermost error created by github.com/pkg/errors.
+func NewErrorLogger(logger log.Logger, opts ...ErrorOption) log.Logger {
+	e := newErrorExpander(opts)
+	return log.LoggerFunc(func(keyvals ...interface{}) error {
+		if !containsError(keyvals) {
+			return logger.Log(keyvals...)
+		}
+
+		out := make([]interface{}, 0, len(keyvals)+6)
+		for i := 0; i < len(keyvals); i += 2 {
+			if i+1 < len(keyvals) {
+				if err, ok := keyvals[i+1].(error); ok && err != nil {
+					out = append(out, e.fields(fmt.Sprint(keyvals[i]), err, 4)...)
+					continue
+				}
+			}
+			out = append(out, keyvals[i:min(i+2, len(keyvals))]...)
+		}
+		return logger.Log(out...)
+	})
+}
+
+func containsError(keyvals []interface{}) bool {
+	for i := 1; i < len(keyvals); i += 2 {
+		if err, ok := keyvals[i].(error); ok && err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorFields returns the structured fields NewErrorLogger would log for err
+// under key. It is useful for adding error details to a single record:
+//
+//	logger.Log(append([]interface{}{"msg", "query failed"}, logutil.ErrorFields("err", err)...)...)
+func ErrorFields(key string, err error, opts ...ErrorOption) []interface{} {
+	return newErrorExpander(opts).fields(key, err, 3)
+}
+
+// fields expands err into key/value pairs. skip is the number of frames to
+// skip when capturing the runtime stack. Like the go-kit JSON logger, it
+// recovers from panics caused by nil pointer receivers, logging err unexpanded.
+func (e *errorExpander) fields(key string, err error, skip int) (kvs []interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			kvs = []interface{}{key, err}
+		}
+	}()
+
+	var (
+		causes []string
+		types  []string
+		stack  []uintptr
+	)
+	types = append(types, fmt.Sprintf("%T", err))
+	prev := err.Error()
+	for cause, depth := unwrap(err), 0; cause != nil && depth < e.maxCauses; cause, depth = unwrap(cause), depth+1 {
+		// github.com/pkg/errors wraps each message and stack separately,
+		// skip the wrappers which don't change the message.
+		if msg := cause.Error(); msg != prev {
+			causes = append(causes, msg)
+			types = append(types, fmt.Sprintf("%T", cause))
+			prev = msg
+		}
+	}
+	for cause, depth := err, 0; cause != nil && depth <= e.maxCauses; cause, depth = unwrap(cause), depth+1 {
+		if st, ok := cause.(interface{ StackTrace() pkgerrors.StackTrace }); ok {
+			stack = stack[:0]
+			for _, f := range st.StackTrace() {
+				stack = append(stack, uintptr(f))
+			}
+		}
+	}
+	if len(stack) == 0 && e.runtimeStack {
+		pcs := make([]uintptr, e.maxFrames)
+		stack = pcs[:runtime.Callers(skip, pcs)]
+	}
+
+	kvs = []interface{}{key, err.Error()}
+	if len(causes) > 0 {
+		kvs = append(kvs, key+".causes", causes)
+	}
+	kvs = append(kvs, key+".types", types)
+	if len(stack) > 0 {
+		kvs = append(kvs, key+".stack", e.formatStack(stack))
+	}
+	return kvs
+}
+
+func (e *errorExpander) formatStack(pcs []uintptr) []string {
+	var out []string
+	frames := runtime.CallersFrames(pcs)
+	for len(out) < e.maxFrames {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			out = append(out, fmt.Sprintf("%s %s:%d", frame.Function, filepath.Base(frame.File), frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+// unwrap returns the error wrapped by err, using either the standard library
+// Unwrap method or the Cause method from github.com/pkg/errors.
+func unwrap(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+	if c, ok := err.(interface{ Cause() error }); ok {
+		if cause := c.Cause(); cause != err {
+			return cause
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package logutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kolide/kit/logutil/logtest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type customError struct{}
+
+func (customError) Error() string { return "custom" }
+
+func TestErrorLogger(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name       string
+		err        error
+		opts       []ErrorOption
+		causes     []string
+		types      []string
+		wantStack  bool
+		stackFrame string
+	}{
+		{
+			name:       "pkg/errors",
+			err:        errors.Wrap(errors.New("connection refused"), "opening db"),
+			causes:     []string{"connection refused"},
+			types:      []string{"*errors.withStack", "*errors.fundamental"},
+			wantStack:  true,
+			stackFrame: "errors_test.go:",
+		},
+		{
+			name:   "stdlib wrapping",
+			err:    fmt.Errorf("reading config: %w", customError{}),
+			causes: []string{"custom"},
+			types:  []string{"*fmt.wrapError", "logutil.customError"},
+		},
+		{
+			name:       "runtime stack",
+			err:        customError{},
+			opts:       []ErrorOption{WithRuntimeStack()},
+			types:      []string{"logutil.customError"},
+			wantStack:  true,
+			stackFrame: "TestErrorLogger",
+		},
+		{
+			name:   "max causes",
+			err:    fmt.Errorf("a: %w", fmt.Errorf("b: %w", customError{})),
+			opts:   []ErrorOption{WithMaxCauses(1)},
+			causes: []string{"b: custom"},
+			types:  []string{"*fmt.wrapError", "*fmt.wrapError"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := logtest.New()
+			logger := NewErrorLogger(rec, tt.opts...)
+			require.NoError(t, logger.Log("msg", "failed", "err", tt.err, "attempt", 1))
+
+			records := rec.Records()
+			require.Len(t, records, 1)
+			r := records[0]
+			require.True(t, r.Has("msg", "failed"))
+			require.True(t, r.Has("attempt", 1))
+			require.True(t, r.Has("err", tt.err.Error()))
+
+			causes, ok := r.Value("err.causes")
+			if tt.causes == nil {
+				require.False(t, ok)
+			} else {
+				require.Equal(t, tt.causes, causes)
+			}
+
+			types, _ := r.Value("err.types")
+			require.Equal(t, tt.types, types)
+
+			stack, ok := r.Value("err.stack")
+			require.Equal(t, tt.wantStack, ok)
+			if tt.wantStack {
+				frames := stack.([]string)
+				require.NotEmpty(t, frames)
+				require.True(t, len(frames) <= 16)
+				require.True(t, strings.Contains(frames[0], tt.stackFrame), frames[0])
+			}
+		})
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("boom")
+	fields := ErrorFields("error", err, WithMaxStackFrames(1))
+	require.Equal(t, "error", fields[0])
+	require.Equal(t, "boom", fields[1])
+	require.Equal(t, "error.stack", fields[4])
+	require.Len(t, fields[5], 1)
+
+	rec := logtest.New()
+	require.NoError(t, NewErrorLogger(rec).Log("msg", "no errors", "err", nil))
+	require.Equal(t, logtest.Record{"msg", "no errors", "err", nil}, rec.Records()[0])
+}
+
+type pointerError struct{ msg string }
+
+func (e *pointerError) Error() string { return e.msg }
+
+func TestErrorLoggerNilPointer(t *testing.T) {
+	t.Parallel()
+
+	var err *pointerError
+	rec := logtest.New()
+	require.NotPanics(t, func() {
+		require.NoError(t, NewErrorLogger(rec).Log("msg", "failed", "err", err))
+	})
+	require.Equal(t, logtest.Record{"msg", "failed", "err", err}, rec.Records()[0])
+	require.Equal(t, []interface{}{"err", err}, ErrorFields("err", err))
+}
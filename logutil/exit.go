@@ -0,0 +1,138 @@
+package logutil
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Syncer is implemented by log sinks which buffer writes, like *os.File and
+// *RotatingFile.
+type Syncer interface {
+	Sync() error
+}
+
+// ExitOption configures an ExitHandler.
+type ExitOption func(*ExitHandler)
+
+// WithExitTimeout sets the total time allowed for shutdown hooks to run.
+// Hooks which have not run when the timeout expires are skipped.
+// The default is 10 seconds.
+func WithExitTimeout(d time.Duration) ExitOption {
+	return func(h *ExitHandler) {
+		h.timeout = d
+	}
+}
+
+// WithExitCode sets the exit code used by Fatal. The default is 1.
+func WithExitCode(code int) ExitOption {
+	return func(h *ExitHandler) {
+		h.code = code
+	}
+}
+
+type exitHook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// ExitHandler exits the process gracefully, running registered shutdown
+// hooks and flushing log sinks before calling os.Exit. Use it in place of
+// Fatal when deferred cleanup, like flushing trace exporters or closing DB
+// connections, must not be skipped.
+type ExitHandler struct {
+	logger  log.Logger
+	timeout time.Duration
+	code    int
+	exit    func(int)
+
+	mu      sync.Mutex
+	hooks   []exitHook
+	syncers []Syncer
+	once    sync.Once
+}
+
+// NewExitHandler creates an ExitHandler which logs to logger.
+func NewExitHandler(logger log.Logger, opts ...ExitOption) *ExitHandler {
+	h := &ExitHandler{
+		logger:  logger,
+		timeout: 10 * time.Second,
+		code:    1,
+		exit:    os.Exit,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// OnExit registers a shutdown hook. Hooks run in the reverse order of their
+// registration, like deferred calls. The context passed to fn is cancelled
+// when the exit timeout expires.
+func (h *ExitHandler) OnExit(name string, fn func(context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, exitHook{name: name, fn: fn})
+}
+
+// AddSyncer registers a log sink which is flushed after all shutdown hooks
+// have run.
+func (h *ExitHandler) AddSyncer(s Syncer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.syncers = append(h.syncers, s)
+}
+
+// Fatal logs args at error level and exits the process with the configured
+// exit code after running the shutdown hooks.
+func (h *ExitHandler) Fatal(args ...interface{}) {
+	level.Error(h.logger).Log(args...)
+	h.Exit(h.code)
+}
+
+// Exit runs the shutdown hooks, flushes the registered sinks and exits the
+// process with code. Only the first call has any effect, concurrent calls
+// block until the process exits.
+func (h *ExitHandler) Exit(code int) {
+	h.once.Do(func() {
+		h.shutdown()
+		h.exit(code)
+	})
+}
+
+func (h *ExitHandler) shutdown() {
+	h.mu.Lock()
+	hooks := make([]exitHook, len(h.hooks))
+	copy(hooks, h.hooks)
+	syncers := make([]Syncer, len(h.syncers))
+	copy(syncers, h.syncers)
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+run:
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		errc := make(chan error, 1)
+		go func() { errc <- hook.fn(ctx) }()
+
+		select {
+		case err := <-errc:
+			if err != nil {
+				level.Error(h.logger).Log("msg", "shutdown hook failed", "hook", hook.name, "err", err)
+			}
+		case <-ctx.Done():
+			level.Error(h.logger).Log("msg", "shutdown hooks timed out", "hook", hook.name, "timeout", h.timeout)
+			break run
+		}
+	}
+
+	for _, s := range syncers {
+		s.Sync()
+	}
+}
@@ -0,0 +1,67 @@
+package logutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kolide/kit/logutil/logtest"
+	"github.com/stretchr/testify/require"
+)
+
+type syncCounter struct {
+	mu    sync.Mutex
+	syncs int
+}
+
+func (s *syncCounter) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncs++
+	return nil
+}
+
+func TestExitHandler(t *testing.T) {
+	t.Parallel()
+
+	rec := logtest.New()
+	h := NewExitHandler(rec, WithExitCode(3), WithExitTimeout(50*time.Millisecond))
+
+	var (
+		mu    sync.Mutex
+		order []string
+		codes []int
+	)
+	h.exit = func(code int) { codes = append(codes, code) }
+	hook := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return err
+		}
+	}
+	h.OnExit("skipped", hook("skipped", nil))
+	h.OnExit("hang", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	h.OnExit("first", hook("first", nil))
+	h.OnExit("second", hook("second", errors.New("flush failed")))
+	syncer := &syncCounter{}
+	h.AddSyncer(syncer)
+
+	h.Fatal("msg", "unrecoverable", "err", errors.New("boom"))
+	h.Fatal("msg", "only the first call exits")
+
+	require.Equal(t, []int{3}, codes)
+	require.Equal(t, []string{"second", "first"}, order)
+	require.Equal(t, 1, syncer.syncs)
+
+	require.Len(t, rec.FindLevel("error"), 4)
+	rec.AssertLogged(t, "msg", "unrecoverable")
+	rec.AssertLogged(t, "hook", "second")
+	rec.AssertCount(t, 1, "msg", "shutdown hooks timed out")
+}
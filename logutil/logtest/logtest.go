@@ -0,0 +1,173 @@
+// Package logtest provides a Go Kit logger which captures records in memory,
+// so tests can assert on what was logged.
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Record is a single captured log record, as a list of key/value pairs.
+type Record []interface{}
+
+// Value returns the value logged with key, if any.
+func (r Record) Value(key interface{}) (interface{}, bool) {
+	for i := 0; i < len(r); i += 2 {
+		if keysEqual(r[i], key) {
+			if i+1 < len(r) {
+				return r[i+1], true
+			}
+			return log.ErrMissingValue, true
+		}
+	}
+	return nil, false
+}
+
+// Has reports whether the record contains key with a value matching value.
+// Values match if they are deeply equal or have the same string form, so a
+// Go Kit level value matches its name, like "error".
+func (r Record) Has(key, value interface{}) bool {
+	v, ok := r.Value(key)
+	return ok && valuesEqual(v, value)
+}
+
+// Level returns the name of the record's level. The level is found by its
+// Go Kit level value, or by a string value logged with the "level" or
+// "severity" keys. An empty string is returned if the record has no level.
+func (r Record) Level() string {
+	for i := 1; i < len(r); i += 2 {
+		if v, ok := r[i].(level.Value); ok {
+			return v.String()
+		}
+	}
+	for _, key := range []string{"level", "severity"} {
+		if v, ok := r.Value(key); ok {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// Logger is a Go Kit logger which captures records in memory.
+// It is safe for concurrent use.
+type Logger struct {
+	tb testing.TB
+
+	mu      sync.Mutex
+	records []Record
+}
+
+// New creates a Logger which captures records in memory.
+func New() *Logger {
+	return &Logger{}
+}
+
+// NewT creates a Logger which captures records in memory and also writes
+// each record to tb.Log, so the output of failing tests includes the logs.
+// The Logger must not be used after the test has completed.
+func NewT(tb testing.TB) *Logger {
+	return &Logger{tb: tb}
+}
+
+// Log captures keyvals as a Record.
+func (l *Logger) Log(keyvals ...interface{}) error {
+	r := make(Record, len(keyvals))
+	copy(r, keyvals)
+
+	l.mu.Lock()
+	l.records = append(l.records, r)
+	l.mu.Unlock()
+
+	if l.tb != nil {
+		l.tb.Helper()
+		l.tb.Log(r...)
+	}
+	return nil
+}
+
+// Records returns all captured records in the order they were logged.
+func (l *Logger) Records() []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records := make([]Record, len(l.records))
+	copy(records, l.records)
+	return records
+}
+
+// Reset discards all captured records.
+func (l *Logger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = nil
+}
+
+// Find returns the records which contain key with a value matching value.
+// See Record.Has for how values are matched.
+func (l *Logger) Find(key, value interface{}) []Record {
+	return l.filter(func(r Record) bool { return r.Has(key, value) })
+}
+
+// FindLevel returns the records logged at lvl, like "error" or "debug".
+func (l *Logger) FindLevel(lvl string) []Record {
+	return l.filter(func(r Record) bool { return r.Level() == lvl })
+}
+
+// Count returns the number of records which contain key with a value
+// matching value.
+func (l *Logger) Count(key, value interface{}) int {
+	return len(l.Find(key, value))
+}
+
+// AssertCount fails the test if the number of records containing key with a
+// value matching value is not want. It returns whether the assertion passed.
+func (l *Logger) AssertCount(tb testing.TB, want int, key, value interface{}) bool {
+	tb.Helper()
+	if have := l.Count(key, value); have != want {
+		tb.Errorf("log records with %v=%v: have %d, want %d", key, value, have, want)
+		return false
+	}
+	return true
+}
+
+// AssertLogged fails the test unless at least one record contains key with a
+// value matching value. It returns whether the assertion passed.
+func (l *Logger) AssertLogged(tb testing.TB, key, value interface{}) bool {
+	tb.Helper()
+	if l.Count(key, value) == 0 {
+		tb.Errorf("no log records with %v=%v", key, value)
+		return false
+	}
+	return true
+}
+
+// AssertNotLogged fails the test if any record contains key with a value
+// matching value. It returns whether the assertion passed.
+func (l *Logger) AssertNotLogged(tb testing.TB, key, value interface{}) bool {
+	tb.Helper()
+	return l.AssertCount(tb, 0, key, value)
+}
+
+func (l *Logger) filter(match func(Record) bool) []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var found []Record
+	for _, r := range l.records {
+		if match(r) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func keysEqual(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func valuesEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b) || fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -0,0 +1,104 @@
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	logger := NewT(t)
+	base := log.With(logger, "component", "test")
+	level.Info(base).Log("msg", "starting", "port", 8080)
+	level.Error(base).Log("msg", "failed", "err", errors.New("boom"))
+	log.With(logger, "severity", "debug").Log("msg", "details")
+
+	require.Len(t, logger.Records(), 3)
+	require.Equal(t, 2, logger.Count("component", "test"))
+	require.Equal(t, 1, logger.Count("port", 8080))
+	require.Equal(t, 1, logger.Count("err", "boom"))
+	require.Equal(t, 0, logger.Count("msg", "missing"))
+
+	errs := logger.FindLevel("error")
+	require.Len(t, errs, 1)
+	require.True(t, errs[0].Has("msg", "failed"))
+	require.True(t, errs[0].Has(level.Key(), "error"))
+	require.Len(t, logger.FindLevel("debug"), 1)
+
+	require.True(t, logger.AssertCount(t, 1, "msg", "starting"))
+	require.True(t, logger.AssertLogged(t, "msg", "details"))
+	require.True(t, logger.AssertNotLogged(t, "msg", "missing"))
+
+	logger.Reset()
+	require.Empty(t, logger.Records())
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		record Record
+		key    interface{}
+		value  interface{}
+		found  bool
+		level  string
+	}{
+		{
+			record: Record{"msg", "hello", level.Key(), level.WarnValue()},
+			key:    "msg",
+			value:  "hello",
+			found:  true,
+			level:  "warn",
+		},
+		{
+			record: Record{"severity", "info", "odd"},
+			key:    "odd",
+			value:  log.ErrMissingValue,
+			found:  true,
+			level:  "info",
+		},
+		{
+			record: Record{"msg", "hello"},
+			key:    "missing",
+			found:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+
+			v, ok := tt.record.Value(tt.key)
+			require.Equal(t, tt.found, ok)
+			require.Equal(t, tt.value, v)
+			require.Equal(t, tt.level, tt.record.Level())
+		})
+	}
+}
+
+func ExampleLogger_Find() {
+	logger := New()
+
+	// code under test logs through the go-kit interface
+	checkers := map[string]error{"db": errors.New("connection refused"), "cache": nil}
+	for _, name := range []string{"cache", "db"} {
+		if err := checkers[name]; err != nil {
+			logger.Log("err", err, "health-checker", name)
+		}
+	}
+
+	failed := logger.Find("health-checker", "db")
+	err, _ := failed[0].Value("err")
+	fmt.Println(len(failed), err)
+	fmt.Println(len(logger.Find("health-checker", "cache")))
+	// Output:
+	// 1 connection refused
+	// 0
+}
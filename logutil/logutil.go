@@ -9,8 +9,10 @@ import (
 )
 
 // Fatal logs a error message and exits the process.
+// Fatal does not run any shutdown hooks, use an ExitHandler to flush logs and
+// release resources before exiting.
 func Fatal(logger log.Logger, args ...interface{}) {
-	level.Info(logger).Log(args...)
+	level.Error(logger).Log(args...)
 	os.Exit(1)
 }
 
@@ -64,7 +66,7 @@ func newLogger(debug bool, base log.Logger) log.Logger {
 		lev = level.AllowDebug()
 	}
 
-	base = log.With(base, "caller", log.Caller(6))
+	base = log.With(callerOverride{base}, "caller", log.Caller(6))
 
 	var swapLogger log.SwapLogger
 	swapLogger.Swap(level.NewFilter(base, lev))
@@ -72,3 +74,21 @@ func newLogger(debug bool, base log.Logger) log.Logger {
 	go swapLevelHandler(base, &swapLogger, debug)
 	return &swapLogger
 }
+
+// callerOverride drops the "caller" added by newLogger when a record brings
+// its own, like the records from a slog.Handler, whose caller would otherwise
+// be in the slog package.
+type callerOverride struct {
+	next log.Logger
+}
+
+func (l callerOverride) Log(keyvals ...interface{}) error {
+	if len(keyvals) >= 2 && keyvals[0] == "caller" {
+		for i := 2; i < len(keyvals); i += 2 {
+			if keyvals[i] == "caller" {
+				return l.next.Log(keyvals[2:]...)
+			}
+		}
+	}
+	return l.next.Log(keyvals...)
+}
@@ -0,0 +1,251 @@
+package logutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// RingRecord is a log record captured by a RingBuffer.
+type RingRecord struct {
+	Seq   uint64    `json:"seq"`
+	Time  time.Time `json:"time"`
+	Level string    `json:"level,omitempty"`
+	Line  string    `json:"line"`
+}
+
+// RingOption configures a RingBuffer.
+type RingOption func(*RingBuffer)
+
+// WithRingMinLevel only keeps records logged at lvl or above, where lvl is
+// one of "debug", "info", "warn" or "error". Records without a level are
+// always kept. By default all records are kept.
+func WithRingMinLevel(lvl string) RingOption {
+	return func(b *RingBuffer) {
+		b.minLevel = levelRank(lvl)
+	}
+}
+
+// RingBuffer keeps a bounded number of recent log records in memory, so they
+// can be inspected without access to the log backend. Records are added by the
+// logger returned from Logger and can be viewed and streamed through Handler.
+// It is safe for concurrent use.
+type RingBuffer struct {
+	size     int
+	minLevel int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	records []RingRecord
+	next    int
+	seq     uint64
+	subs    map[chan RingRecord]struct{}
+}
+
+// NewRingBuffer creates a RingBuffer which holds up to size records.
+// A size of 0 or less keeps no records, but records are still streamed.
+func NewRingBuffer(size int, opts ...RingOption) *RingBuffer {
+	if size < 0 {
+		size = 0
+	}
+	b := &RingBuffer{
+		size:    size,
+		now:     time.Now,
+		records: make([]RingRecord, 0, size),
+		subs:    make(map[chan RingRecord]struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Logger returns a logger which copies every record to the buffer before
+// passing it to next.
+func (b *RingBuffer) Logger(next log.Logger) log.Logger {
+	return log.LoggerFunc(func(keyvals ...interface{}) error {
+		b.add(keyvals)
+		return next.Log(keyvals...)
+	})
+}
+
+// Records returns the buffered records, oldest first.
+func (b *RingBuffer) Records() []RingRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	records := make([]RingRecord, 0, len(b.records))
+	records = append(records, b.records[b.next:]...)
+	records = append(records, b.records[:b.next]...)
+	return records
+}
+
+func (b *RingBuffer) add(keyvals []interface{}) {
+	lvl := recordLevel(keyvals)
+	if b.minLevel > 0 && lvl != "" && levelRank(lvl) < b.minLevel {
+		return
+	}
+
+	// render the record immediately, since values may change after Log returns.
+	var buf bytes.Buffer
+	if err := log.NewLogfmtLogger(&buf).Log(keyvals...); err != nil {
+		fmt.Fprint(&buf, keyvals...)
+	}
+	r := RingRecord{
+		Time:  b.now(),
+		Level: lvl,
+		Line:  strings.TrimSuffix(buf.String(), "\n"),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	r.Seq = b.seq
+	if len(b.records) < b.size {
+		b.records = append(b.records, r)
+	} else if b.size > 0 {
+		b.records[b.next] = r
+		b.next = (b.next + 1) % b.size
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- r:
+		default:
+			// drop records for slow subscribers rather than block logging
+		}
+	}
+}
+
+func (b *RingBuffer) subscribe() chan RingRecord {
+	ch := make(chan RingRecord, 64)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *RingBuffer) unsubscribe(ch chan RingRecord) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+}
+
+// Handler returns an http.Handler which serves the buffered records.
+// The following query parameters are supported:
+//
+//	q       only return records containing the string
+//	level   only return records at the level or above
+//	limit   return at most the given number of the most recent records
+//	format  "json" to return a JSON array instead of plain text
+//	stream  "true" to stream new records as server-sent events
+//
+// Records are also streamed when the request accepts text/event-stream.
+func (b *RingBuffer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		match := ringFilter(query.Get("q"), query.Get("level"))
+
+		if query.Get("stream") == "true" || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			b.stream(w, r, match)
+			return
+		}
+
+		var records []RingRecord
+		for _, rec := range b.Records() {
+			if match(rec) {
+				records = append(records, rec)
+			}
+		}
+		if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit >= 0 && limit < len(records) {
+			records = records[len(records)-limit:]
+		}
+
+		if query.Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			if records == nil {
+				records = []RingRecord{}
+			}
+			json.NewEncoder(w).Encode(records)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, rec := range records {
+			fmt.Fprintln(w, rec.Line)
+		}
+	})
+}
+
+func (b *RingBuffer) stream(w http.ResponseWriter, r *http.Request, match func(RingRecord) bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case rec := <-ch:
+			if !match(rec) {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", rec.Seq, rec.Line)
+			flusher.Flush()
+		}
+	}
+}
+
+func ringFilter(q, lvl string) func(RingRecord) bool {
+	minLevel := levelRank(lvl)
+	return func(r RingRecord) bool {
+		if q != "" && !strings.Contains(r.Line, q) {
+			return false
+		}
+		return minLevel == 0 || levelRank(r.Level) >= minLevel
+	}
+}
+
+// recordLevel returns the name of the Go Kit level value in keyvals.
+func recordLevel(keyvals []interface{}) string {
+	for i := 1; i < len(keyvals); i += 2 {
+		if v, ok := keyvals[i].(level.Value); ok {
+			return v.String()
+		}
+	}
+	return ""
+}
+
+// levelRank orders level names from least to most severe, returning 0 for
+// unknown levels.
+func levelRank(lvl string) int {
+	switch strings.ToLower(lvl) {
+	case "debug":
+		return 1
+	case "info":
+		return 2
+	case "warn":
+		return 3
+	case "error":
+		return 4
+	default:
+		return 0
+	}
+}
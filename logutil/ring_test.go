@@ -0,0 +1,136 @@
+package logutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingBuffer(t *testing.T) {
+	t.Parallel()
+
+	buf := NewRingBuffer(3, WithRingMinLevel("info"))
+	logger := buf.Logger(log.NewNopLogger())
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		level.Info(logger).Log("msg", msg)
+	}
+	level.Debug(logger).Log("msg", "filtered")
+	level.Error(logger).Log("msg", "five", "err", "boom")
+
+	records := buf.Records()
+	require.Len(t, records, 3)
+	require.Equal(t, "level=info msg=three", records[0].Line)
+	require.Equal(t, "level=info msg=four", records[1].Line)
+	require.Equal(t, "level=error msg=five err=boom", records[2].Line)
+	require.Equal(t, "error", records[2].Level)
+	require.Equal(t, uint64(5), records[2].Seq)
+}
+
+func TestRingBufferEmpty(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int{0, -1} {
+		b := NewRingBuffer(size)
+		require.NoError(t, b.Logger(log.NewNopLogger()).Log("msg", "dropped"))
+		require.Empty(t, b.Records())
+	}
+}
+
+func TestRingBufferHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := NewRingBuffer(10)
+	logger := buf.Logger(log.NewNopLogger())
+	level.Info(logger).Log("msg", "request", "path", "/a")
+	level.Info(logger).Log("msg", "request", "path", "/b")
+	level.Error(logger).Log("msg", "failed", "path", "/b")
+
+	var tests = []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "",
+			want:  "level=info msg=request path=/a\nlevel=info msg=request path=/b\nlevel=error msg=failed path=/b\n",
+		},
+		{
+			query: "?q=/b",
+			want:  "level=info msg=request path=/b\nlevel=error msg=failed path=/b\n",
+		},
+		{
+			query: "?level=error",
+			want:  "level=error msg=failed path=/b\n",
+		},
+		{
+			query: "?limit=1",
+			want:  "level=error msg=failed path=/b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequestWithContext(t.Context(), "GET", "/logs"+tt.query, nil)
+			buf.Handler().ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, tt.want, rr.Body.String())
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequestWithContext(t.Context(), "GET", "/logs?format=json&q=failed", nil)
+	buf.Handler().ServeHTTP(rr, req)
+	var records []RingRecord
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &records))
+	require.Len(t, records, 1)
+	require.Equal(t, "error", records[0].Level)
+}
+
+func TestRingBufferStream(t *testing.T) {
+	t.Parallel()
+
+	buf := NewRingBuffer(10)
+	logger := buf.Logger(log.NewNopLogger())
+
+	srv := httptest.NewServer(buf.Handler())
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(t.Context(), "GET", srv.URL+"?stream=true&q=wanted", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the subscription is registered before the headers are flushed
+	logger.Log("msg", "ignored")
+	logger.Log("msg", "wanted")
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+				lines <- line
+			}
+		}
+	}()
+
+	select {
+	case line := <-lines:
+		require.Equal(t, "data: msg=wanted", line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for streamed record")
+	}
+}
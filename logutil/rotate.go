@@ -0,0 +1,409 @@
+package logutil
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// backupTimeFormat is used to name rotated files. It sorts lexically in
+// chronological order. Files rotated within the same millisecond get a
+// "-N" suffix to keep their names unique.
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateOption configures a RotatingFile.
+type RotateOption func(*RotatingFile)
+
+// WithMaxSize rotates the file before a write would grow it beyond
+// maxBytes. The default is 100 MB. A value of 0 disables size based rotation.
+func WithMaxSize(maxBytes int64) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxSize = maxBytes
+	}
+}
+
+// WithMaxAge rotates the file once it has been open for longer than d.
+// By default files are not rotated based on age.
+func WithMaxAge(d time.Duration) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxAge = d
+	}
+}
+
+// WithMaxBackups sets the number of rotated files to keep. Older files are
+// removed after each rotation. The default is 5. A value of 0 keeps all files.
+func WithMaxBackups(n int) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxBackups = n
+	}
+}
+
+// WithCompress compresses rotated files with gzip.
+func WithCompress() RotateOption {
+	return func(f *RotatingFile) {
+		f.compress = true
+	}
+}
+
+// WithReopenOnSIGHUP reopens the file when the process receives SIGHUP. This
+// allows an external tool like logrotate to move the file out of the way.
+// It has no effect on Windows.
+func WithReopenOnSIGHUP() RotateOption {
+	return func(f *RotatingFile) {
+		f.reopenOnSignal = true
+	}
+}
+
+// RotatingFile is an io.WriteCloser which writes to a file, rotating it
+// based on size and age. Rotated files are renamed with a timestamp suffix,
+// optionally compressed, and removed once there are more than the configured
+// number of backups. It is safe for concurrent use.
+type RotatingFile struct {
+	path           string
+	maxSize        int64
+	maxAge         time.Duration
+	maxBackups     int
+	compress       bool
+	reopenOnSignal bool
+	now            func() time.Time
+
+	mu       sync.Mutex
+	closed   bool
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// the timestamp and suffix of the last backup name, see backupName
+	lastBackupTS string
+	lastBackupN  int
+
+	millMu sync.Mutex
+	wg     sync.WaitGroup
+	done   chan struct{}
+}
+
+// NewRotatingFile opens or creates the file at path for appending, creating
+// the parent directory if necessary.
+func NewRotatingFile(path string, opts ...RotateOption) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    100 * 1024 * 1024,
+		maxBackups: 5,
+		now:        time.Now,
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating log directory: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	if f.reopenOnSignal {
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			reopenHandler(f, f.done)
+		}()
+	}
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first if needed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		// a previous rotation failed to open the new file
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it with a timestamp suffix and
+// opens a new file at the original path.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file at the original path without renaming
+// it. Use Reopen after the file has been moved by an external tool.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return fmt.Errorf("closing log file: %w", err)
+		}
+		f.file = nil
+	}
+	return f.open()
+}
+
+// Sync commits the current contents of the file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file and waits for any background compression or cleanup
+// of rotated files to finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return os.ErrClosed
+	}
+	f.closed = true
+	close(f.done)
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	// never rotate an empty file
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+n > f.maxSize {
+		return true
+	}
+	return f.maxAge > 0 && f.now().Sub(f.openedAt) >= f.maxAge
+}
+
+// open must be called with f.mu held.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// rotate must be called with f.mu held.
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return fmt.Errorf("closing log file: %w", err)
+		}
+		f.file = nil
+	}
+
+	backup := f.backupName()
+	if err := os.Rename(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("renaming log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.mill(backup)
+	}()
+	return nil
+}
+
+// backupName returns an unused name for a backup rotated now. Backups rotated
+// within the same millisecond get an increasing -N suffix. The suffix never
+// goes back down, even if older backups have been removed in the meantime, so
+// that the names keep sorting in rotation order.
+// backupName must be called with f.mu held.
+func (f *RotatingFile) backupName() string {
+	ts := f.now().UTC().Format(backupTimeFormat)
+	n := 0
+	if ts == f.lastBackupTS {
+		n = f.lastBackupN + 1
+	}
+	name := backupPath(f.path, ts, n)
+	for exists(name) || exists(name+".gz") {
+		n++
+		name = backupPath(f.path, ts, n)
+	}
+	f.lastBackupTS, f.lastBackupN = ts, n
+	return name
+}
+
+func backupPath(path, ts string, n int) string {
+	if n == 0 {
+		return path + "." + ts
+	}
+	return fmt.Sprintf("%s.%s-%d", path, ts, n)
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// mill compresses the newly rotated backup and removes old backups.
+// Errors are ignored, since there is nowhere sensible to report them.
+func (f *RotatingFile) mill(backup string) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if f.compress {
+		if err := compressFile(backup); err == nil {
+			os.Remove(backup)
+		}
+	}
+
+	if f.maxBackups <= 0 {
+		return
+	}
+	backups, err := f.backups()
+	if err != nil || len(backups) <= f.maxBackups {
+		return
+	}
+	for _, b := range backups[:len(backups)-f.maxBackups] {
+		os.Remove(b)
+	}
+}
+
+// backups returns the rotated files, oldest first.
+func (f *RotatingFile) backups() ([]string, error) {
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		name string
+		ts   string
+		n    int
+	}
+	var found []backup
+	for _, m := range matches {
+		ts := strings.TrimSuffix(strings.TrimPrefix(m, f.path+"."), ".gz")
+		n := 0
+		if i := strings.LastIndex(ts, "-"); i >= 0 {
+			var err error
+			if n, err = strconv.Atoi(ts[i+1:]); err != nil {
+				continue
+			}
+			ts = ts[:i]
+		}
+		if _, err := time.Parse(backupTimeFormat, ts); err == nil {
+			found = append(found, backup{name: m, ts: ts, n: n})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].ts != found[j].ts {
+			return found[i].ts < found[j].ts
+		}
+		return found[i].n < found[j].n
+	})
+
+	backups := make([]string, len(found))
+	for i, b := range found {
+		backups[i] = b.name
+	}
+	return backups, nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	gzw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gzw, src); err != nil {
+		return err
+	}
+	if err := gzw.Close(); err != nil {
+		return err
+	}
+	return dst.Close()
+}
+
+// NewServerFileLogger is like NewServerLogger, but writes JSON logs to a
+// RotatingFile at path instead of os.Stderr. The caller should Close the
+// returned file when the logger is no longer used.
+func NewServerFileLogger(debug bool, path string, opts ...RotateOption) (log.Logger, *RotatingFile, error) {
+	f, err := NewRotatingFile(path, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := log.NewJSONLogger(log.NewSyncWriter(f))
+	return newLogger(debug, base), f, nil
+}
+
+// NewCLIFileLogger is like NewCLILogger, but writes logfmt logs to a
+// RotatingFile at path instead of os.Stderr. The caller should Close the
+// returned file when the logger is no longer used.
+func NewCLIFileLogger(debug bool, path string, opts ...RotateOption) (log.Logger, *RotatingFile, error) {
+	f, err := NewRotatingFile(path, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := log.NewLogfmtLogger(log.NewSyncWriter(f))
+	return newLogger(debug, base), f, nil
+}
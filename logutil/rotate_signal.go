@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package logutil
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func reopenHandler(f *RotatingFile, done <-chan struct{}) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+	for {
+		select {
+		case <-done:
+			return
+		case <-sigChan:
+			// there is nowhere to report a failure, the next write
+			// will attempt to open the file again.
+			f.Reopen()
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package logutil
+
+func reopenHandler(f *RotatingFile, done <-chan struct{}) {
+	// noop for now
+}
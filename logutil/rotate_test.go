@@ -0,0 +1,170 @@
+package logutil
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name        string
+		opts        []RotateOption
+		writes      int
+		advance     time.Duration
+		wantBackups int
+		compressed  bool
+	}{
+		{
+			name:        "size",
+			opts:        []RotateOption{WithMaxSize(10)},
+			writes:      4,
+			wantBackups: 3,
+		},
+		{
+			name:        "max backups",
+			opts:        []RotateOption{WithMaxSize(10), WithMaxBackups(2)},
+			writes:      6,
+			wantBackups: 2,
+		},
+		{
+			name:        "compress",
+			opts:        []RotateOption{WithMaxSize(10), WithCompress()},
+			writes:      3,
+			wantBackups: 2,
+			compressed:  true,
+		},
+		{
+			name:        "age",
+			opts:        []RotateOption{WithMaxSize(0), WithMaxAge(time.Hour)},
+			writes:      3,
+			advance:     time.Hour,
+			wantBackups: 2,
+		},
+		{
+			name:        "no rotation",
+			opts:        []RotateOption{WithMaxSize(0)},
+			writes:      5,
+			advance:     time.Hour,
+			wantBackups: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "logs", "app.log")
+			f, err := NewRotatingFile(path, tt.opts...)
+			require.NoError(t, err)
+
+			now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+			f.now = func() time.Time { return now }
+			f.openedAt = now
+
+			for i := 0; i < tt.writes; i++ {
+				// advance the clock so each backup gets a unique name
+				now = now.Add(time.Second + tt.advance)
+				_, err := f.Write([]byte("0123456789"))
+				require.NoError(t, err)
+			}
+			require.NoError(t, f.Close())
+
+			backups, err := f.backups()
+			require.NoError(t, err)
+			require.Len(t, backups, tt.wantBackups)
+			for _, b := range backups {
+				require.Equal(t, tt.compressed, strings.HasSuffix(b, ".gz"), b)
+				require.Equal(t, "0123456789", readLogFile(t, b))
+			}
+			require.Equal(t, "0123456789", readLogFile(t, path)[:10])
+
+			_, err = f.Write([]byte("closed"))
+			require.True(t, errors.Is(err, os.ErrClosed))
+		})
+	}
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	t.Parallel()
+
+	for _, compress := range []bool{false, true} {
+		opts := []RotateOption{WithMaxSize(0)}
+		if compress {
+			opts = append(opts, WithCompress())
+		}
+		path := filepath.Join(t.TempDir(), "app.log")
+		f, err := NewRotatingFile(path, opts...)
+		require.NoError(t, err)
+
+		now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+		f.now = func() time.Time { return now }
+
+		for i := 0; i < 12; i++ {
+			_, err := fmt.Fprintf(f, "write %02d", i)
+			require.NoError(t, err)
+			require.NoError(t, f.Rotate())
+		}
+		require.NoError(t, f.Close())
+
+		// no backup is overwritten, and they are ordered by rotation
+		backups, err := f.backups()
+		require.NoError(t, err)
+		require.Len(t, backups, 5)
+		for i, b := range backups {
+			require.Equal(t, fmt.Sprintf("write %02d", i+7), readLogFile(t, b), b)
+		}
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("before"))
+	require.NoError(t, err)
+
+	// simulate an external logrotate
+	moved := filepath.Join(dir, "app.log.1")
+	require.NoError(t, os.Rename(path, moved))
+	require.NoError(t, f.Reopen())
+
+	_, err = f.Write([]byte("after"))
+	require.NoError(t, err)
+
+	require.Equal(t, "before", readLogFile(t, moved))
+	require.Equal(t, "after", readLogFile(t, path))
+}
+
+func readLogFile(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gzr, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		defer gzr.Close()
+		r = gzr
+	}
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,211 @@
+package logutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// SampleOption configures a sampling logger created by NewSampledLogger.
+type SampleOption func(*sampler)
+
+// WithSampleKey sets the key whose value identifies repeated records.
+// Records which share the same value for key are sampled together.
+// Records which do not contain the key are never sampled.
+// The default key is "msg".
+func WithSampleKey(key interface{}) SampleOption {
+	return func(s *sampler) {
+		s.key = key
+	}
+}
+
+// WithSampleInterval sets the length of each sampling window. Counters are
+// reset and summary records are emitted at the end of every window.
+// The default interval is one second, which is also used if d is not positive.
+func WithSampleInterval(d time.Duration) SampleOption {
+	return func(s *sampler) {
+		s.interval = d
+	}
+}
+
+// WithSampleFirst sets the number of records for each key which are logged
+// at the start of every window before sampling begins. The default is 10.
+func WithSampleFirst(n int) SampleOption {
+	return func(s *sampler) {
+		s.first = n
+	}
+}
+
+// WithSampleThereafter logs every nth record for a key once the initial
+// records allowed by WithSampleFirst have been logged in a window.
+// A value of 0, the default, drops every record beyond the initial ones.
+func WithSampleThereafter(n int) SampleOption {
+	return func(s *sampler) {
+		s.thereafter = n
+	}
+}
+
+// WithErrorFloor sets the number of error level records for each key which
+// are always logged in a window, regardless of sampling. Error records beyond
+// the floor are sampled like any other record.
+// A negative value, the default, means error records are never dropped.
+func WithErrorFloor(n int) SampleOption {
+	return func(s *sampler) {
+		s.errorFloor = n
+	}
+}
+
+// NewSampledLogger wraps logger, sampling repeated records so that a single
+// hot code path cannot flood the logs.
+// At the end of every window a summary record with the number of suppressed
+// records is logged for each key which had records dropped.
+// Summaries are emitted in the background until ctx is cancelled.
+func NewSampledLogger(ctx context.Context, logger log.Logger, opts ...SampleOption) log.Logger {
+	s := &sampler{
+		next:       logger,
+		key:        "msg",
+		interval:   time.Second,
+		first:      10,
+		errorFloor: -1,
+		now:        time.Now,
+		counts:     make(map[string]*sampleCount),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.interval <= 0 {
+		s.interval = time.Second
+	}
+	s.windowStart = s.now()
+
+	go s.run(ctx)
+	return s
+}
+
+type sampleCount struct {
+	total      int
+	errors     int
+	suppressed int
+}
+
+type sampler struct {
+	next       log.Logger
+	key        interface{}
+	interval   time.Duration
+	first      int
+	thereafter int
+	errorFloor int
+	now        func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]*sampleCount
+}
+
+func (s *sampler) Log(keyvals ...interface{}) error {
+	id, ok := s.sampleID(keyvals)
+	if !ok {
+		return s.next.Log(keyvals...)
+	}
+
+	s.mu.Lock()
+	summaries := s.rotate()
+	c, ok := s.counts[id]
+	if !ok {
+		c = &sampleCount{}
+		s.counts[id] = c
+	}
+	c.total++
+	allow := s.allow(c, isErrorRecord(keyvals))
+	if !allow {
+		c.suppressed++
+	}
+	s.mu.Unlock()
+
+	s.logSummaries(summaries)
+	if !allow {
+		return nil
+	}
+	return s.next.Log(keyvals...)
+}
+
+// allow decides whether the current record should be logged.
+// It must be called with s.mu held.
+func (s *sampler) allow(c *sampleCount, isError bool) bool {
+	if isError {
+		c.errors++
+		if s.errorFloor < 0 || c.errors <= s.errorFloor {
+			return true
+		}
+	}
+	if c.total <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (c.total-s.first)%s.thereafter == 0
+}
+
+// sampleID returns the string form of the sample key's value in keyvals.
+func (s *sampler) sampleID(keyvals []interface{}) (string, bool) {
+	for i := 0; i < len(keyvals)-1; i += 2 {
+		if keyvals[i] == s.key {
+			return fmt.Sprint(keyvals[i+1]), true
+		}
+	}
+	return "", false
+}
+
+// rotate starts a new window if the current one has expired, returning the
+// suppressed counts of the window which ended.
+// It must be called with s.mu held.
+func (s *sampler) rotate() map[string]int {
+	now := s.now()
+	if now.Sub(s.windowStart) < s.interval {
+		return nil
+	}
+
+	summaries := make(map[string]int)
+	for id, c := range s.counts {
+		if c.suppressed > 0 {
+			summaries[id] = c.suppressed
+		}
+	}
+	s.counts = make(map[string]*sampleCount)
+	s.windowStart = now
+	return summaries
+}
+
+func (s *sampler) logSummaries(summaries map[string]int) {
+	for id, suppressed := range summaries {
+		level.Info(s.next).Log(
+			"msg", "suppressed repeated log records",
+			"sample_key", id,
+			"suppressed", suppressed,
+			"interval", s.interval,
+		)
+	}
+}
+
+func (s *sampler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			summaries := s.rotate()
+			s.mu.Unlock()
+			s.logSummaries(summaries)
+		}
+	}
+}
+
+// isErrorRecord reports whether keyvals contains the error level value.
+func isErrorRecord(keyvals []interface{}) bool {
+	return recordLevel(keyvals) == level.ErrorValue().String()
+}
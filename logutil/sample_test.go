@@ -0,0 +1,113 @@
+package logutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/kolide/kit/logutil/logtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampledLogger(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name       string
+		opts       []SampleOption
+		errors     bool
+		logged     int
+		suppressed int
+	}{
+		{
+			name:       "first only",
+			opts:       []SampleOption{WithSampleFirst(3)},
+			logged:     3,
+			suppressed: 7,
+		},
+		{
+			name:       "thereafter",
+			opts:       []SampleOption{WithSampleFirst(2), WithSampleThereafter(4)},
+			logged:     4,
+			suppressed: 6,
+		},
+		{
+			name:   "errors are never dropped by default",
+			opts:   []SampleOption{WithSampleFirst(1)},
+			errors: true,
+			logged: 10,
+		},
+		{
+			name:       "error floor",
+			opts:       []SampleOption{WithSampleFirst(1), WithErrorFloor(5)},
+			errors:     true,
+			logged:     5,
+			suppressed: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			now := time.Now()
+			opts := append([]SampleOption{WithSampleInterval(time.Hour)}, tt.opts...)
+			rec := logtest.New()
+			logger := NewSampledLogger(ctx, rec, opts...)
+			s := logger.(*sampler)
+			s.mu.Lock()
+			s.now = func() time.Time { return now }
+			s.windowStart = now
+			s.mu.Unlock()
+
+			for i := 0; i < 10; i++ {
+				l := level.Info(logger)
+				if tt.errors {
+					l = level.Error(logger)
+				}
+				require.NoError(t, l.Log("msg", "hot path"))
+			}
+			require.Equal(t, tt.logged, rec.Count("msg", "hot path"))
+
+			// a record with a different key is sampled separately
+			require.NoError(t, logger.Log("msg", "other"))
+			require.Equal(t, 1, rec.Count("msg", "other"))
+
+			// records without the sample key are never sampled
+			for i := 0; i < 20; i++ {
+				require.NoError(t, logger.Log("event", "unkeyed"))
+			}
+			require.Equal(t, 20, rec.Count("event", "unkeyed"))
+
+			// moving into the next window emits a summary
+			s.mu.Lock()
+			s.now = func() time.Time { return now.Add(time.Hour) }
+			s.mu.Unlock()
+			require.NoError(t, logger.Log("msg", "hot path"))
+			if tt.suppressed > 0 {
+				require.Equal(t, 1, rec.Count("suppressed", tt.suppressed))
+			} else {
+				require.Equal(t, 0, rec.Count("msg", "suppressed repeated log records"))
+			}
+		})
+	}
+}
+
+func TestSampledLoggerInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		rec := logtest.New()
+		logger := NewSampledLogger(ctx, rec, WithSampleInterval(d))
+		require.Equal(t, time.Second, logger.(*sampler).interval)
+		require.NoError(t, logger.Log("msg", "hello"))
+	}
+}
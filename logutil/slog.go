@@ -0,0 +1,241 @@
+package logutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// NewSlogHandler returns a slog.Handler which writes records to a Go Kit
+// logger. The slog level is converted to the matching Go Kit level value, so
+// loggers created by NewServerLogger and NewCLILogger filter and label records
+// the same way as their own. Attributes in groups are logged with keys joined
+// by a ".".
+//
+// The handler does not add a timestamp, as the loggers in this package add one.
+// It logs the call site of each record as "caller", replacing the one added by
+// NewServerLogger and NewCLILogger, which would point into the slog package.
+func NewSlogHandler(logger log.Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+type slogHandler struct {
+	logger log.Logger
+	attrs  []interface{}
+	prefix string
+}
+
+// Enabled always returns true, leaving filtering to the Go Kit logger.
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	kvs := make([]interface{}, 0, 6+len(h.attrs)+2*r.NumAttrs())
+	kvs = append(kvs, level.Key(), goKitLevel(r.Level), "msg", r.Message)
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		kvs = append(kvs, "caller", fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line))
+	}
+	kvs = append(kvs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = appendSlogAttr(kvs, h.prefix, a)
+		return true
+	})
+	return h.logger.Log(kvs...)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = make([]interface{}, len(h.attrs), len(h.attrs)+2*len(attrs))
+	copy(h2.attrs, h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendSlogAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendSlogAttr appends a as key/value pairs to kvs, flattening groups.
+func appendSlogAttr(kvs []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			kvs = appendSlogAttr(kvs, prefix, ga)
+		}
+		return kvs
+	}
+	return append(kvs, prefix+a.Key, a.Value.Any())
+}
+
+func goKitLevel(l slog.Level) level.Value {
+	switch {
+	case l >= slog.LevelError:
+		return level.ErrorValue()
+	case l >= slog.LevelWarn:
+		return level.WarnValue()
+	case l >= slog.LevelInfo:
+		return level.InfoValue()
+	default:
+		return level.DebugValue()
+	}
+}
+
+// NewSlogLogger returns a Go Kit logger which writes records to a
+// slog.Handler. Go Kit level values, and string level values logged with the
+// "level" or "severity" keys, are converted to the matching slog level.
+// The value of the "msg" key becomes the record message. Records without a
+// level are logged at slog.LevelInfo.
+func NewSlogLogger(h slog.Handler) log.Logger {
+	return &slogLogger{handler: h}
+}
+
+type slogLogger struct {
+	handler slog.Handler
+}
+
+func (l *slogLogger) Log(keyvals ...interface{}) error {
+	var (
+		lvl   = slog.LevelInfo
+		msg   string
+		attrs = make([]slog.Attr, 0, (len(keyvals)+1)/2)
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = log.ErrMissingValue
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		key := fmt.Sprint(keyvals[i])
+
+		if lv, ok := v.(level.Value); ok {
+			lvl = slogLevel(lv.String())
+			continue
+		}
+		if s, ok := v.(string); ok {
+			if key == "level" || key == "severity" {
+				if parsed, ok := parseLevel(s); ok {
+					lvl = parsed
+					continue
+				}
+			}
+			if key == "msg" && msg == "" {
+				msg = s
+				continue
+			}
+		}
+		attrs = append(attrs, slog.Any(key, v))
+	}
+
+	ctx := context.Background()
+	if !l.handler.Enabled(ctx, lvl) {
+		return nil
+	}
+	r := slog.NewRecord(time.Now(), lvl, msg, 0)
+	r.AddAttrs(attrs...)
+	return l.handler.Handle(ctx, r)
+}
+
+func slogLevel(s string) slog.Level {
+	l, _ := parseLevel(s)
+	return l
+}
+
+func parseLevel(s string) (slog.Level, bool) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn", "warning":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	default:
+		return slog.LevelInfo, false
+	}
+}
+
+// NewSlogServerLogger creates a standard slog logger for Kolide services.
+// It is the log/slog equivalent of NewServerLogger, writing JSON records to
+// os.Stderr with "ts", "severity" and "caller" fields.
+// The acceptable level can be swapped by sending SIGUSR2 to the process.
+func NewSlogServerLogger(debug bool) *slog.Logger {
+	return newSlogLogger(debug, func(opts *slog.HandlerOptions) slog.Handler {
+		return slog.NewJSONHandler(os.Stderr, opts)
+	})
+}
+
+// NewSlogCLILogger creates a standard slog logger for Kolide CLI tools.
+// It is the log/slog equivalent of NewCLILogger, writing logfmt style records
+// to os.Stderr with "ts", "severity" and "caller" fields.
+// The acceptable level can be swapped by sending SIGUSR2 to the process.
+func NewSlogCLILogger(debug bool) *slog.Logger {
+	return newSlogLogger(debug, func(opts *slog.HandlerOptions) slog.Handler {
+		return slog.NewTextHandler(os.Stderr, opts)
+	})
+}
+
+func newSlogLogger(debug bool, newHandler func(*slog.HandlerOptions) slog.Handler) *slog.Logger {
+	lev := new(slog.LevelVar)
+	if debug {
+		lev.Set(slog.LevelDebug)
+	}
+
+	h := newHandler(&slog.HandlerOptions{
+		AddSource:   true,
+		Level:       lev,
+		ReplaceAttr: replaceSlogAttr,
+	})
+	logger := slog.New(h)
+
+	go swapSlogLevelHandler(logger, lev, debug)
+	return logger
+}
+
+// replaceSlogAttr renames the built in slog attributes to match the keys and
+// formats used by the Go Kit loggers in this package.
+func replaceSlogAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		if t, ok := a.Value.Any().(time.Time); ok {
+			return slog.String("ts", t.UTC().Format(time.RFC3339Nano))
+		}
+		a.Key = "ts"
+	case slog.LevelKey:
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("severity", goKitLevel(l).String())
+		}
+		a.Key = "severity"
+	case slog.SourceKey:
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.String("caller", fmt.Sprintf("%s:%d", filepath.Base(src.File), src.Line))
+		}
+		a.Key = "caller"
+	}
+	return a
+}
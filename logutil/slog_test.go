@@ -0,0 +1,128 @@
+package logutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	base := SetLevelKey(log.NewJSONLogger(&buf), "severity")
+	base = level.NewFilter(base, level.AllowInfo())
+
+	logger := slog.New(NewSlogHandler(base)).With("component", "test")
+	logger.WithGroup("req").Warn("slow request", "path", "/", slog.Group("db", "queries", 3))
+	logger.Debug("filtered")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.True(t, strings.HasPrefix(record["caller"].(string), "slog_test.go:"), record["caller"])
+	delete(record, "caller")
+	require.Equal(t, map[string]interface{}{
+		"severity":       "warn",
+		"msg":            "slow request",
+		"component":      "test",
+		"req.path":       "/",
+		"req.db.queries": float64(3),
+	}, record)
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := newLogger(false, log.NewJSONLogger(&buf))
+	slog.New(NewSlogHandler(logger)).Info("from slog")
+	level.Info(logger).Log("msg", "from go-kit")
+
+	dec := json.NewDecoder(&buf)
+	for _, msg := range []string{"from slog", "from go-kit"} {
+		var record map[string]interface{}
+		require.NoError(t, dec.Decode(&record))
+		require.Equal(t, msg, record["msg"])
+		require.True(t, strings.HasPrefix(record["caller"].(string), "slog_test.go:"), record["caller"])
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name    string
+		keyvals []interface{}
+		want    map[string]interface{}
+	}{
+		{
+			name:    "go kit level",
+			keyvals: []interface{}{level.Key(), level.ErrorValue(), "msg", "failed", "attempt", 2},
+			want:    map[string]interface{}{"level": "ERROR", "msg": "failed", "attempt": float64(2)},
+		},
+		{
+			name:    "severity key",
+			keyvals: []interface{}{"severity", "warn", "msg", "careful"},
+			want:    map[string]interface{}{"level": "WARN", "msg": "careful"},
+		},
+		{
+			name:    "no level",
+			keyvals: []interface{}{"msg", "hello", "odd"},
+			want:    map[string]interface{}{"level": "INFO", "msg": "hello", "odd": "(MISSING)"},
+		},
+		{
+			name:    "below handler level",
+			keyvals: []interface{}{level.Key(), level.DebugValue(), "msg", "filtered"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})
+			require.NoError(t, NewSlogLogger(h).Log(tt.keyvals...))
+
+			if tt.want == nil {
+				require.Equal(t, 0, buf.Len())
+				return
+			}
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			require.Equal(t, tt.want, record)
+		})
+	}
+}
+
+func TestReplaceSlogAttr(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		AddSource:   true,
+		ReplaceAttr: replaceSlogAttr,
+	}))
+	logger.Info("hello")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "info", record["severity"])
+	require.Contains(t, record["caller"], "slog_test.go:")
+	require.Contains(t, record, "ts")
+	require.NotContains(t, record, "time")
+}
@@ -4,6 +4,7 @@
 package logutil
 
 import (
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -28,3 +29,18 @@ func swapLevelHandler(base log.Logger, swapLogger *log.SwapLogger, debug bool) {
 		debug = !debug
 	}
 }
+
+func swapSlogLevelHandler(logger *slog.Logger, lev *slog.LevelVar, debug bool) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR2)
+	for {
+		<-sigChan
+		if debug {
+			lev.Set(slog.LevelInfo)
+		} else {
+			lev.Set(slog.LevelDebug)
+		}
+		logger.Info("swapping level", "debug", !debug)
+		debug = !debug
+	}
+}
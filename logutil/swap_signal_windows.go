@@ -3,8 +3,16 @@
 
 package logutil
 
-import "github.com/go-kit/kit/log"
+import (
+	"log/slog"
+
+	"github.com/go-kit/kit/log"
+)
 
 func swapLevelHandler(base log.Logger, swapLogger *log.SwapLogger, debug bool) {
 	// noop for now
 }
+
+func swapSlogLevelHandler(logger *slog.Logger, lev *slog.LevelVar, debug bool) {
+	// noop for now
+}
@@ -98,3 +98,12 @@ func (c ConnectionOptions) String() string {
 	}
 	return s
 }
+
+// Redact implements the logutil.Redactor interface, so that a
+// pgutil.ConnectionOptions can be logged without leaking the password.
+func (c ConnectionOptions) Redact() interface{} {
+	if c.Password != "" {
+		c.Password = "[REDACTED]"
+	}
+	return c.String()
+}